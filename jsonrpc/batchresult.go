@@ -0,0 +1,136 @@
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoResponse marks a batch entry for which the server sent no response.
+var ErrNoResponse = errors.New("no response received")
+
+// BatchEntry pairs a batch request with the response carrying its ID.
+type BatchEntry struct {
+	Request  *RPCRequest
+	Response *RPCResponse
+}
+
+// Missing reports whether no response was received for the request.
+func (e BatchEntry) Missing() bool { return e.Response == nil }
+
+// Err returns ErrNoResponse for a missing response, the RPC error of a failed
+// one, and nil otherwise.
+func (e BatchEntry) Err() error {
+	if e.Response == nil {
+		return ErrNoResponse
+	}
+	if e.Response.Error != nil {
+		return e.Response.Error
+	}
+	return nil
+}
+
+// BatchResult correlates batch responses with their requests strictly by ID.
+type BatchResult struct {
+	// Entries holds one entry per request, in request order.
+	Entries []BatchEntry
+	// Duplicates holds extra responses for an ID that was already answered.
+	Duplicates RPCResponses
+	// Unmatched holds responses whose ID belongs to no request, such as the
+	// id-less error a server sends for an unparsable batch.
+	Unmatched RPCResponses
+}
+
+// CorrelateBatch matches resps to reqs by ID, regardless of the order the
+// server answered in. When requests share an ID, responses are handed out to
+// them in order.
+func CorrelateBatch(reqs RPCRequests, resps RPCResponses) *BatchResult {
+	res := &BatchResult{Entries: make([]BatchEntry, len(reqs))}
+	pending := make(map[int][]int, len(reqs))
+	for i, req := range reqs {
+		res.Entries[i].Request = req
+		pending[req.ID] = append(pending[req.ID], i)
+	}
+	answered := make(map[int]bool, len(resps))
+	for _, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		idx := pending[resp.ID]
+		switch {
+		case len(idx) > 0:
+			res.Entries[idx[0]].Response = resp
+			pending[resp.ID] = idx[1:]
+			answered[resp.ID] = true
+		case answered[resp.ID]:
+			res.Duplicates = append(res.Duplicates, resp)
+		default:
+			res.Unmatched = append(res.Unmatched, resp)
+		}
+	}
+	return res
+}
+
+// Missing returns the requests that received no response.
+func (r *BatchResult) Missing() RPCRequests {
+	var out RPCRequests
+	for _, e := range r.Entries {
+		if e.Missing() {
+			out = append(out, e.Request)
+		}
+	}
+	return out
+}
+
+// Complete reports whether every request got exactly one response and no
+// stray responses arrived.
+func (r *BatchResult) Complete() bool {
+	return len(r.Missing()) == 0 && len(r.Duplicates) == 0 && len(r.Unmatched) == 0
+}
+
+// Errors joins the errors of all entries that failed or got no response,
+// each prefixed with its ID and method. It returns nil when all succeeded.
+func (r *BatchResult) Errors() error {
+	var errs []error
+	for _, e := range r.Entries {
+		if err := e.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("id %d %v(): %w", e.Request.ID, e.Request.Method, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PartialBatchError is returned with the responses decoded so far when the
+// context ends while a batch response is still being read.
+type PartialBatchError struct {
+	// Undecoded lists the IDs of requests whose responses were not decoded.
+	Undecoded []int
+	Err       error
+}
+
+// newPartialBatchError records which of reqs are not answered by resps.
+func newPartialBatchError(reqs []*RPCRequest, resps RPCResponses, err error) *PartialBatchError {
+	res := CorrelateBatch(reqs, resps)
+	e := &PartialBatchError{Err: err}
+	for _, req := range res.Missing() {
+		e.Undecoded = append(e.Undecoded, req.ID)
+	}
+	return e
+}
+
+// Error implements the error interface for PartialBatchError.
+func (e *PartialBatchError) Error() string {
+	return fmt.Sprintf("batch interrupted with %d responses undecoded: %v", len(e.Undecoded), e.Err)
+}
+
+// Unwrap returns the context error that interrupted the batch.
+func (e *PartialBatchError) Unwrap() error { return e.Err }
+
+// OrderedResults returns one response per request in reqs, aligned with the
+// request slice and nil where no response arrived.
+func (res RPCResponses) OrderedResults(reqs RPCRequests) RPCResponses {
+	out := make(RPCResponses, len(reqs))
+	for i, e := range CorrelateBatch(reqs, res).Entries {
+		out[i] = e.Response
+	}
+	return out
+}
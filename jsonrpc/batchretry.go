@@ -0,0 +1,98 @@
+package jsonrpc
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// BatchRetryPolicy re-sends only the entries of a batch that failed with a
+// retryable RPC error, keeping the successful responses.
+type BatchRetryPolicy struct {
+	// MaxAttempts bounds the total sends per entry, including the first.
+	MaxAttempts int
+	// Backoff is the wait before the first retry; it doubles up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// RetryableCodes lists retryable error codes; nil means ErrLimitExceeded,
+	// the code many servers use for rate-limited calls.
+	RetryableCodes []int
+	// Retryable overrides RetryableCodes when set.
+	Retryable func(*RPCError) bool
+}
+
+// retryable reports whether err should be retried under p.
+func (p *BatchRetryPolicy) retryable(err *RPCError) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	if p.RetryableCodes == nil {
+		return err.Code == ErrLimitExceeded
+	}
+	return slices.Contains(p.RetryableCodes, err.Code)
+}
+
+// retryBatch re-sends the requests whose responses failed with a retryable
+// error and merges the new responses into resps by ID. Each re-send carries
+// an idempotency key of its own.
+func (c *rpcClient) retryBatch(ctx context.Context, reqs []*RPCRequest, resps RPCResponses) (RPCResponses, error) {
+	p := c.batchRetry
+	if p == nil {
+		return resps, nil
+	}
+	backoff := p.Backoff
+	for attempt := 1; attempt < p.MaxAttempts; attempt++ {
+		failed := make(map[int]bool)
+		for _, r := range resps {
+			if r != nil && r.Error != nil && p.retryable(r.Error) {
+				failed[r.ID] = true
+			}
+		}
+		if len(failed) == 0 {
+			return resps, nil
+		}
+		var retry []*RPCRequest
+		for _, req := range reqs {
+			if failed[req.ID] {
+				retry = append(retry, req)
+			}
+		}
+		if err := sleepContext(ctx, backoff); err != nil {
+			return resps, err
+		}
+		if p.MaxBackoff > 0 {
+			backoff = min(backoff*2, p.MaxBackoff)
+		} else {
+			backoff *= 2
+		}
+		again, err := c.doBatchCall(withRetryIdempotencyKey(ctx, attempt), retry)
+		if err != nil {
+			return resps, err
+		}
+		byID := RPCResponses(again).AsMap()
+		for i, r := range resps {
+			if r == nil || !failed[r.ID] {
+				continue
+			}
+			if nr, ok := byID[r.ID]; ok {
+				resps[i] = nr
+			}
+		}
+	}
+	return resps, nil
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,108 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// splitBatch partitions reqs into chunks that respect the configured count and byte limits.
+// A single request larger than the byte limit is sent on its own.
+func (c *rpcClient) splitBatch(reqs []*RPCRequest) ([][]*RPCRequest, error) {
+	if c.maxBatchSize <= 0 && c.maxBatchBytes <= 0 {
+		return [][]*RPCRequest{reqs}, nil
+	}
+	var (
+		chunks [][]*RPCRequest
+		cur    []*RPCRequest
+		size   = 2 // enclosing brackets
+	)
+	for _, req := range reqs {
+		n := 0
+		if c.maxBatchBytes > 0 {
+			shaped, err := c.shapeRequest(req)
+			if err != nil {
+				return nil, err
+			}
+			b, err := c.json.Marshal(shaped)
+			if err != nil {
+				return nil, err
+			}
+			n = len(b) + 1 // separating comma
+		}
+		full := c.maxBatchSize > 0 && len(cur) >= c.maxBatchSize
+		tooBig := c.maxBatchBytes > 0 && size+n > c.maxBatchBytes
+		if len(cur) > 0 && (full || tooBig) {
+			chunks = append(chunks, cur)
+			cur, size = nil, 2
+		}
+		cur = append(cur, req)
+		size += n
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks, nil
+}
+
+// sendChunkedBatch splits reqs as needed, sends the chunks with up to
+// BatchParallelism in flight (all at once when zero, one by one when one) and
+// stitches the responses back in request order.
+func (c *rpcClient) sendChunkedBatch(ctx context.Context, reqs []*RPCRequest) (RPCResponses, error) {
+	chunks, err := c.splitBatch(reqs)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 1 {
+		return c.sendBatch(ctx, chunks[0])
+	}
+	parallelism := c.batchParallelism
+	if parallelism <= 0 {
+		parallelism = len(chunks)
+	}
+	sem := make(chan struct{}, parallelism)
+	results := make([]RPCResponses, len(chunks))
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			results[i], errs[i] = c.sendBatch(ctx, chunk)
+		}()
+	}
+	wg.Wait()
+	merged := make(RPCResponses, 0, len(reqs))
+	for _, r := range results {
+		merged = append(merged, r...)
+	}
+	merged = orderByRequest(reqs, merged)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return merged, newPartialBatchError(reqs, merged, ctxErr)
+	}
+	return merged, errors.Join(errs...)
+}
+
+// orderByRequest sorts resps into the order of the requests they answer.
+// Responses whose ID matches no request keep their relative order at the end.
+func orderByRequest(reqs []*RPCRequest, resps RPCResponses) RPCResponses {
+	pos := make(map[int]int, len(reqs))
+	for i, req := range reqs {
+		if _, dup := pos[req.ID]; !dup {
+			pos[req.ID] = i
+		}
+	}
+	rank := func(r *RPCResponse) int {
+		if r == nil {
+			return len(reqs)
+		}
+		if i, ok := pos[r.ID]; ok {
+			return i
+		}
+		return len(reqs)
+	}
+	slices.SortStableFunc(resps, func(a, b *RPCResponse) int { return rank(a) - rank(b) })
+	return resps
+}
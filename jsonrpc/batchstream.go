@@ -0,0 +1,174 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// CallBatchStream makes a batch call and invokes handle for each response as it is decoded,
+// so large batches never need to be held in memory at once. Returning an error from handle
+// stops decoding and is returned to the caller. Oversized batches are sent chunk by chunk.
+// Request IDs are reassigned and params encoded and validated as by CallBatch.
+// A result failing its schema stops decoding and is returned instead of
+// being handled.
+func (c *rpcClient) CallBatchStream(ctx context.Context, requests RPCRequests, handle func(*RPCResponse) error) error {
+	if len(requests) == 0 {
+		return errors.New("empty request list")
+	}
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	codec := c.codecFor(ctx)
+	byID := make(map[int]*RPCRequest, len(requests))
+	for i := range requests {
+		if !c.keepBatchIDs {
+			id := atomic.AddInt64(&c.requestIDCounter, 1)
+			requests[i].ID = int(id)
+		}
+		p, err := c.encodeParams(requests[i].Params, codec)
+		if err != nil {
+			return fmt.Errorf("rpc call %v() on %v: %w", requests[i].Method, c.endpoint, err)
+		}
+		requests[i].Params = p
+		if err := c.validateParams(requests[i]); err != nil {
+			return err
+		}
+		byID[requests[i].ID] = requests[i]
+	}
+	chunks, err := c.splitBatch(requests)
+	if err != nil {
+		return err
+	}
+	if err := c.waitRate(ctx, requests...); err != nil {
+		return err
+	}
+	start := time.Now()
+	ctx = c.withBatchProgress(ctx, requests)
+	observed := func(resp *RPCResponse) error {
+		if resp != nil {
+			resp.codec = codec
+			if req, ok := byID[resp.ID]; ok {
+				delete(byID, resp.ID)
+				c.observe(req, resp, nil, time.Since(start))
+				if err := c.validateResult(req.Method, resp); err != nil {
+					return err
+				}
+			}
+		}
+		return handle(resp)
+	}
+	c.withProfilerLabels(ctx, "batch", func(ctx context.Context) {
+		for _, chunk := range chunks {
+			if err = c.streamBatch(ctx, chunk, observed); err != nil {
+				break
+			}
+		}
+	})
+	// Entries left unanswered failed with the batch, or were skipped by the
+	// server.
+	unanswered := err
+	if unanswered == nil {
+		unanswered = ErrNoResponse
+	}
+	for _, req := range byID {
+		c.observe(req, nil, unanswered, time.Since(start))
+	}
+	return err
+}
+
+// decodeBatch reads a JSON array of responses, element by element when the
+// decoder supports tokens and in one piece otherwise.
+func (c *rpcClient) decodeBatch(dec JSONDecoder, fn func(*RPCResponse) error) error {
+	tdec, ok := dec.(JSONTokenDecoder)
+	if !ok {
+		var wires []*wireResponse
+		if err := dec.Decode(&wires); err != nil {
+			return err
+		}
+		for _, wire := range wires {
+			resp, err := wire.toResponse(c.json)
+			if err != nil {
+				return err
+			}
+			if err := fn(resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.decodeBatchStream(tdec, fn)
+}
+
+// decodeBatchStream reads a JSON array of responses element by element.
+func (c *rpcClient) decodeBatchStream(dec JSONTokenDecoder, fn func(*RPCResponse) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected array, got %v", tok)
+	}
+	for dec.More() {
+		resp, err := c.decodeResponse(dec)
+		if err != nil {
+			return err
+		}
+		if err := fn(resp); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// decodeResponse decodes one response through a pooled wireResponse.
+func (c *rpcClient) decodeResponse(dec JSONDecoder) (*RPCResponse, error) {
+	wire := acquireWireResponse()
+	defer releaseWireResponse(wire)
+	// Decoding through a pointer reuses the pooled value and leaves it nil for a JSON null.
+	p := wire
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	return p.toResponse(c.json)
+}
+
+// BatchObjectError is returned when a nonconforming server answers a batch
+// with a single response object, typically an error such as an auth failure,
+// instead of an array.
+type BatchObjectError struct {
+	StatusCode int
+	Response   *RPCResponse
+}
+
+// Error implements the error interface for BatchObjectError.
+func (e *BatchObjectError) Error() string {
+	if e.Response != nil && e.Response.Error != nil {
+		return fmt.Sprintf("batch answered with a single error object (status %d): %v", e.StatusCode, e.Response.Error)
+	}
+	return fmt.Sprintf("batch answered with a single object instead of an array (status %d)", e.StatusCode)
+}
+
+// Unwrap returns the RPC error carried by the object, if any.
+func (e *BatchObjectError) Unwrap() error {
+	if e.Response == nil || e.Response.Error == nil {
+		return nil
+	}
+	return e.Response.Error
+}
+
+// decodeBatchObject decodes the single object a batch was answered with
+// into a *BatchObjectError.
+func (c *rpcClient) decodeBatchObject(dec JSONDecoder, status int, info *HTTPInfo) error {
+	resp, err := c.decodeResponse(dec)
+	if err != nil {
+		return fmt.Errorf("decode batch: %w", err)
+	}
+	if resp != nil {
+		resp.HTTP = info
+	}
+	return &BatchObjectError{StatusCode: status, Response: resp}
+}
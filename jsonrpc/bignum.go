@@ -0,0 +1,133 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// BigNumberEncoding selects how *big.Int and *big.Float values travel on the wire.
+// big.Float fields decoded by GetObject get 64 bits of precision unless preset;
+// use GetBigFloat to keep every digit.
+type BigNumberEncoding int
+
+const (
+	// BigNumbersDefault leaves big numbers to the JSON engine: big.Int as a
+	// number and big.Float as a string.
+	BigNumbersDefault BigNumberEncoding = iota
+	// BigNumbersAsNumbers sends big numbers as JSON numbers and accepts
+	// numbers or strings, including 0x-prefixed hex, in results.
+	BigNumbersAsNumbers
+	// BigNumbersAsStrings sends big numbers as decimal strings and accepts
+	// numbers or strings in results.
+	BigNumbersAsStrings
+)
+
+var (
+	bigIntType   = reflect.TypeFor[big.Int]()
+	bigFloatType = reflect.TypeFor[big.Float]()
+)
+
+// WithBigNumbers sets the call's big number encoding, overriding
+// RPCClientOpts.BigNumbers.
+func WithBigNumbers(e BigNumberEncoding) CallOption {
+	return func(o *callOptions) { o.bigNumbers = &e }
+}
+
+// bigNumbersFor returns the per-call or client big number encoding.
+func (c *rpcClient) bigNumbersFor(ctx context.Context) BigNumberEncoding {
+	if e := callOptionsFrom(ctx).bigNumbers; e != nil {
+		return *e
+	}
+	return c.bigNumbers
+}
+
+// encodeBig re-encodes a big.Int or big.Float param per enc.
+func encodeBig(rv reflect.Value, enc BigNumberEncoding) (any, bool) {
+	if enc == BigNumbersDefault {
+		return nil, false
+	}
+	var text string
+	switch rv.Type() {
+	case bigIntType:
+		v := rv.Interface().(big.Int)
+		text = v.String()
+	case bigFloatType:
+		v := rv.Interface().(big.Float)
+		text = v.Text('g', -1)
+	default:
+		return nil, false
+	}
+	if enc == BigNumbersAsStrings {
+		return text, true
+	}
+	return json.Number(text), true
+}
+
+// decodeBig normalizes a result value so that it unmarshals into big.Int
+// (which wants a number) or big.Float (which wants a string).
+func decodeBig(t reflect.Type, g any, enc BigNumberEncoding) (any, bool) {
+	if enc == BigNumbersDefault {
+		return nil, false
+	}
+	switch t {
+	case bigIntType:
+		s, ok := g.(string)
+		if !ok {
+			return g, true
+		}
+		v, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return g, true
+		}
+		return json.Number(v.String()), true
+	case bigFloatType:
+		if n, ok := g.(json.Number); ok {
+			return string(n), true
+		}
+		return g, true
+	}
+	return nil, false
+}
+
+// GetBigInt extracts an arbitrary-precision integer from the response result.
+// Numbers and strings, including 0x-prefixed hex, are accepted.
+func (r *RPCResponse) GetBigInt() (*big.Int, error) {
+	var s string
+	switch val := r.Result.(type) {
+	case json.Number:
+		s = string(val)
+	case string:
+		s = val
+	default:
+		return nil, fmt.Errorf("invalid big int: %v", r.Result)
+	}
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid big int: %v", r.Result)
+	}
+	return v, nil
+}
+
+// GetBigFloat extracts an arbitrary-precision decimal from the response result.
+// The precision is wide enough to hold every digit of the value.
+func (r *RPCResponse) GetBigFloat() (*big.Float, error) {
+	var s string
+	switch val := r.Result.(type) {
+	case json.Number:
+		s = string(val)
+	case string:
+		s = val
+	default:
+		return nil, fmt.Errorf("invalid big float: %v", r.Result)
+	}
+	prec := uint(max(64, len(strings.TrimLeft(s, "+-"))*4))
+	v, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid big float: %v", r.Result)
+	}
+	return v, nil
+}
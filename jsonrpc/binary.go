@@ -0,0 +1,118 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BinaryEncoding selects how []byte values travel on the wire.
+type BinaryEncoding int
+
+const (
+	// BinaryBase64 leaves []byte to its JSON encoding, a standard base64 string.
+	BinaryBase64 BinaryEncoding = iota
+	// BinaryHex encodes []byte as a 0x-prefixed hex string.
+	BinaryHex
+)
+
+var (
+	marshalerType   = reflect.TypeFor[json.Marshaler]()
+	unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// WithBinaryEncoding sets the call's binary encoding, overriding
+// RPCClientOpts.BinaryEncoding.
+func WithBinaryEncoding(e BinaryEncoding) CallOption {
+	return func(o *callOptions) { o.binary = &e }
+}
+
+// binaryFor returns the per-call or client binary encoding.
+func (c *rpcClient) binaryFor(ctx context.Context) BinaryEncoding {
+	if e := callOptionsFrom(ctx).binary; e != nil {
+		return *e
+	}
+	return c.binary
+}
+
+// isBytes reports whether t is a byte slice without its own JSON methods,
+// which excludes json.RawMessage and HexBytes.
+func isBytes(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	return !t.Implements(marshalerType) && !reflect.PointerTo(t).Implements(unmarshalerType)
+}
+
+// encodeBinary re-encodes a []byte param per enc.
+func encodeBinary(rv reflect.Value, enc BinaryEncoding) (any, bool) {
+	if enc != BinaryHex || !isBytes(rv.Type()) {
+		return nil, false
+	}
+	return "0x" + hex.EncodeToString(rv.Bytes()), true
+}
+
+// decodeBinary turns a hex string into the base64 form []byte unmarshals from.
+func decodeBinary(t reflect.Type, g any, enc BinaryEncoding) (any, bool) {
+	if enc != BinaryHex || !isBytes(t) {
+		return nil, false
+	}
+	s, ok := g.(string)
+	if !ok {
+		return g, true
+	}
+	b, err := decodeHex(s)
+	if err != nil {
+		return g, true
+	}
+	return base64.StdEncoding.EncodeToString(b), true
+}
+
+// decodeHex decodes s with or without a 0x prefix.
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+// HexBytes is a []byte that always encodes as a 0x-prefixed hex string,
+// whatever the client's BinaryEncoding.
+type HexBytes []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + hex.EncodeToString(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := decodeHex(s)
+	if err != nil {
+		return fmt.Errorf("invalid hex bytes: %w", err)
+	}
+	*b = v
+	return nil
+}
+
+// GetBytes extracts binary data from the response result, decoding it as
+// 0x-prefixed hex or base64 according to the call's BinaryEncoding.
+func (r *RPCResponse) GetBytes() ([]byte, error) {
+	s, ok := r.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid bytes: %v", r.Result)
+	}
+	if r.codec.binary == BinaryHex {
+		return decodeHex(s)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
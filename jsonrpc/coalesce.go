@@ -0,0 +1,134 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultCoalesceMaxSize bounds a coalesced batch when CoalesceMaxSize is zero.
+const defaultCoalesceMaxSize = 100
+
+// coalescer queues individual calls and flushes them as a single batch.
+type coalescer struct {
+	client  *rpcClient
+	window  time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	pending []*pendingCall
+	timer   *time.Timer
+}
+
+// pendingCall is a queued call waiting for its share of a batch response.
+type pendingCall struct {
+	req  *RPCRequest
+	resp *RPCResponse
+	err  error
+	done chan struct{}
+}
+
+// newCoalescer returns nil when coalescing is disabled.
+func newCoalescer(c *rpcClient, window time.Duration, maxSize int) *coalescer {
+	if window <= 0 {
+		return nil
+	}
+	if maxSize <= 0 {
+		maxSize = defaultCoalesceMaxSize
+	}
+	return &coalescer{client: c, window: window, maxSize: maxSize}
+}
+
+// call queues req and waits for its response or for ctx to be done.
+// Queued requests are sent with a background context so one caller's
+// cancellation does not abort the calls it shares a batch with; calls whose
+// options that context would drop are sent on their own instead.
+func (co *coalescer) call(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	if !coalescible(ctx) {
+		return co.client.doCall(ctx, req)
+	}
+	queued := *req
+	p := &pendingCall{req: &queued, done: make(chan struct{})}
+
+	co.mu.Lock()
+	co.pending = append(co.pending, p)
+	if len(co.pending) >= co.maxSize {
+		batch := co.take()
+		co.mu.Unlock()
+		go co.flush(batch)
+	} else {
+		if co.timer == nil {
+			co.timer = time.AfterFunc(co.window, co.flushPending)
+		}
+		co.mu.Unlock()
+	}
+
+	select {
+	case <-p.done:
+		return p.resp, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// coalescible reports whether the call under ctx may share a batch. A
+// timeout or idempotency key of its own, or headers added by interceptors,
+// apply to the HTTP request and cannot be honored by a shared one.
+func coalescible(ctx context.Context) bool {
+	co := callOptionsFrom(ctx)
+	if co.timeout > 0 || co.idempotencyKey != "" {
+		return false
+	}
+	info := CallInfoFromContext(ctx)
+	return info == nil || len(info.Header) == 0
+}
+
+// take removes and returns the queued calls. co.mu must be held.
+func (co *coalescer) take() []*pendingCall {
+	if co.timer != nil {
+		co.timer.Stop()
+		co.timer = nil
+	}
+	batch := co.pending
+	co.pending = nil
+	return batch
+}
+
+// flushPending sends whatever is queued when the window elapses.
+func (co *coalescer) flushPending() {
+	co.mu.Lock()
+	batch := co.take()
+	co.mu.Unlock()
+	co.flush(batch)
+}
+
+// flush sends batch and hands each caller its response.
+func (co *coalescer) flush(batch []*pendingCall) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx := context.Background()
+	if len(batch) == 1 {
+		p := batch[0]
+		p.resp, p.err = co.client.doCall(ctx, p.req)
+		close(p.done)
+		return
+	}
+	reqs := make([]*RPCRequest, len(batch))
+	for i, p := range batch {
+		reqs[i] = p.req
+	}
+	resps, err := co.client.doBatchCall(ctx, reqs)
+	byID := RPCResponses(resps).AsMap()
+	for _, p := range batch {
+		p.resp = byID[p.req.ID]
+		switch {
+		case p.resp == nil && err != nil:
+			p.err = err
+		case p.resp == nil:
+			p.err = fmt.Errorf("rpc call %v(): no response for id %d in coalesced batch", p.req.Method, p.req.ID)
+		}
+		close(p.done)
+	}
+}
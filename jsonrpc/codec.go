@@ -0,0 +1,87 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// wireCodec gathers the value encodings that differ from the JSON engine's
+// defaults, such as Unix timestamps, big numbers sent as strings or hex bytes.
+type wireCodec struct {
+	time   TimeEncoding
+	big    BigNumberEncoding
+	binary BinaryEncoding
+}
+
+// codecFor returns the per-call or client wire codec.
+func (c *rpcClient) codecFor(ctx context.Context) wireCodec {
+	return wireCodec{time: c.timeEncodingFor(ctx), big: c.bigNumbersFor(ctx), binary: c.binaryFor(ctx)}
+}
+
+// isDefault reports whether w leaves every value to the JSON engine.
+func (w wireCodec) isDefault() bool {
+	return w == wireCodec{}
+}
+
+// encodeLeaf re-encodes a param value the codec handles.
+func (w wireCodec) encodeLeaf(rv reflect.Value) (any, bool) {
+	if out, ok := encodeTime(rv, w.time); ok {
+		return out, true
+	}
+	if out, ok := encodeBig(rv, w.big); ok {
+		return out, true
+	}
+	return encodeBinary(rv, w.binary)
+}
+
+// decodeLeaf rewrites a result value so that it unmarshals into t.
+func (w wireCodec) decodeLeaf(t reflect.Type, g any) (any, bool) {
+	if out, ok := decodeTime(t, g, w.time); ok {
+		return out, true
+	}
+	if out, ok := decodeBig(t, g, w.big); ok {
+		return out, true
+	}
+	return decodeBinary(t, g, w.binary)
+}
+
+// encodeParams returns params with the codec's encodings applied.
+func (c *rpcClient) encodeParams(params any, w wireCodec) (any, error) {
+	if _, raw := params.(json.RawMessage); raw || w.isDefault() || params == nil {
+		return params, nil
+	}
+	js, err := c.json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	g, err := decodeGeneric(c.json, js)
+	if err != nil {
+		return nil, err
+	}
+	return rewriteEncoded(reflect.ValueOf(params), g, w.encodeLeaf), nil
+}
+
+// decodeResult rewrites raw so that it unmarshals into to under the codec.
+func decodeResult(engine JSONEngine, raw []byte, to any, w wireCodec) ([]byte, error) {
+	if _, ok := to.(*json.RawMessage); ok || w.isDefault() {
+		return raw, nil
+	}
+	g, err := decodeGeneric(engine, raw)
+	if err != nil {
+		return nil, err
+	}
+	return engine.Marshal(rewriteDecoded(reflect.TypeOf(to), g, w.decodeLeaf))
+}
+
+// decodeGeneric decodes js into maps, slices and json.Number values.
+func decodeGeneric(engine JSONEngine, js []byte) (any, error) {
+	dec := engine.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	var g any
+	if err := dec.Decode(&g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
@@ -0,0 +1,55 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipBody compresses body when it is at least GzipMinBytes long.
+// It reports whether the returned bytes are compressed.
+func (c *rpcClient) gzipBody(body []byte) ([]byte, bool, error) {
+	if c.gzipMinBytes <= 0 || len(body) < c.gzipMinBytes {
+		return body, false, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, false, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// decompressResponse replaces a gzip-encoded body with a decompressing reader.
+func decompressResponse(httpResp *http.Response) error {
+	if !strings.EqualFold(httpResp.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	httpResp.Body = &gzipReadCloser{Reader: zr, zr: zr, body: httpResp.Body}
+	httpResp.Header.Del("Content-Encoding")
+	httpResp.Header.Del("Content-Length")
+	httpResp.ContentLength = -1
+	return nil
+}
+
+// gzipReadCloser closes both the gzip reader and the underlying body.
+type gzipReadCloser struct {
+	io.Reader
+	zr   *gzip.Reader
+	body io.ReadCloser
+}
+
+// Close closes the gzip reader and the underlying body.
+func (b *gzipReadCloser) Close() error {
+	b.zr.Close()
+	return b.body.Close()
+}
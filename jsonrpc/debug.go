@@ -0,0 +1,160 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultDebugMaxBytes caps captured bodies when DebugOpts.MaxBytes is zero.
+const defaultDebugMaxBytes = 64 << 10
+
+// defaultRedactedHeaders are masked when DebugOpts.RedactHeaders is nil.
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// DebugOpts configures wire-level dumps of request and response bytes.
+type DebugOpts struct {
+	// Writer receives a human-readable dump of every exchange.
+	Writer io.Writer
+	// Callback receives every captured exchange.
+	Callback func(*WireDump)
+	// MaxBytes caps the captured bytes per body; negative disables the cap.
+	MaxBytes int
+	// RedactHeaders lists headers whose values are masked in dumps.
+	RedactHeaders []string
+	// Redact rewrites captured bodies before they are handed out.
+	Redact func(body []byte) []byte
+	// Indent pretty-prints request bodies with this indent while debugging.
+	Indent string
+}
+
+// WireDump holds the exact bytes of one HTTP exchange.
+type WireDump struct {
+	Method            string
+	URL               string
+	RequestHeader     http.Header
+	Request           []byte
+	RequestTruncated  bool
+	StatusCode        int
+	ResponseHeader    http.Header
+	Response          []byte
+	ResponseTruncated bool
+	Duration          time.Duration
+	Err               error
+}
+
+// String formats the dump for logging.
+func (d *WireDump) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--> %s %s (%v)\n", d.Method, d.URL, d.Duration)
+	writeDumpHeader(&b, d.RequestHeader)
+	b.Write(d.Request)
+	if d.RequestTruncated {
+		b.WriteString(" [truncated]")
+	}
+	b.WriteString("\n")
+	if d.Err != nil {
+		fmt.Fprintf(&b, "<-- error: %v\n", d.Err)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "<-- %d\n", d.StatusCode)
+	writeDumpHeader(&b, d.ResponseHeader)
+	b.Write(d.Response)
+	if d.ResponseTruncated {
+		b.WriteString(" [truncated]")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// writeDumpHeader writes header lines in wire format.
+func writeDumpHeader(w io.Writer, h http.Header) {
+	for k, vs := range h {
+		for _, v := range vs {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+}
+
+// sendHTTP sends httpReq, capturing a wire dump when debugging is enabled.
+func (c *rpcClient) sendHTTP(httpReq *http.Request, method string) (*http.Response, error) {
+	if c.debug == nil {
+		return c.httpClient.Do(httpReq)
+	}
+	d := &WireDump{
+		Method:        method,
+		URL:           httpReq.URL.Redacted(),
+		RequestHeader: c.redactHeader(httpReq.Header),
+	}
+	if httpReq.GetBody != nil {
+		if body, err := httpReq.GetBody(); err == nil {
+			raw, _ := io.ReadAll(body)
+			d.Request, d.RequestTruncated = c.capture(raw)
+		}
+	}
+	start := time.Now()
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		d.Duration = time.Since(start)
+		d.Err = err
+		c.emitDump(d)
+		return nil, err
+	}
+	raw, err := io.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	d.Duration = time.Since(start)
+	d.StatusCode = httpResp.StatusCode
+	d.ResponseHeader = c.redactHeader(httpResp.Header)
+	d.Response, d.ResponseTruncated = c.capture(raw)
+	d.Err = err
+	c.emitDump(d)
+	if err != nil {
+		return nil, err
+	}
+	httpResp.Body = io.NopCloser(bytes.NewReader(raw))
+	return httpResp, nil
+}
+
+// capture applies the size cap and redaction to a body.
+func (c *rpcClient) capture(raw []byte) ([]byte, bool) {
+	limit := c.debug.MaxBytes
+	if limit == 0 {
+		limit = defaultDebugMaxBytes
+	}
+	truncated := false
+	if limit > 0 && len(raw) > limit {
+		raw, truncated = raw[:limit], true
+	}
+	out := bytes.Clone(raw)
+	if c.debug.Redact != nil {
+		out = c.debug.Redact(out)
+	}
+	return out, truncated
+}
+
+// redactHeader returns a copy of h with sensitive values masked.
+func (c *rpcClient) redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	names := c.debug.RedactHeaders
+	if names == nil {
+		names = defaultRedactedHeaders
+	}
+	for _, name := range names {
+		if out.Get(name) != "" {
+			out.Set(name, "[redacted]")
+		}
+	}
+	return out
+}
+
+// emitDump hands a dump to the configured writer and callback.
+func (c *rpcClient) emitDump(d *WireDump) {
+	if c.debug.Writer != nil {
+		io.WriteString(c.debug.Writer, d.String())
+	}
+	if c.debug.Callback != nil {
+		c.debug.Callback(d)
+	}
+}
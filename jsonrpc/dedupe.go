@@ -0,0 +1,100 @@
+package jsonrpc
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// deduper shares one round trip among concurrent identical calls.
+type deduper struct {
+	all     bool
+	methods []string
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is an in-progress call that later arrivals wait on.
+type flight struct {
+	done chan struct{}
+	resp *RPCResponse
+	err  error
+
+	// waiters counts the callers still waiting; cancel stops the call once
+	// none are left. Both are guarded by deduper.mu.
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// newDeduper returns nil when deduplication is disabled.
+func newDeduper(all bool, methods []string) *deduper {
+	if !all && len(methods) == 0 {
+		return nil
+	}
+	return &deduper{all: all, methods: slices.Clone(methods), flights: make(map[string]*flight)}
+}
+
+// enabled reports whether calls to method are deduplicated.
+func (d *deduper) enabled(method string) bool {
+	return d != nil && (d.all || slices.Contains(d.methods, method))
+}
+
+// do runs fn once per key among concurrent callers. The call runs on a
+// context detached from any one caller, so a caller giving up does not fail
+// the others; it is canceled only when every caller has given up. It sends
+// a copy of req, which the caller may release once it stops waiting. Every
+// caller receives its own copy of the shared response carrying its own
+// request ID.
+func (d *deduper) do(ctx context.Context, key string, req *RPCRequest, fn func(context.Context, *RPCRequest) (*RPCResponse, error)) (*RPCResponse, error) {
+	d.mu.Lock()
+	f, ok := d.flights[key]
+	if !ok {
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		d.flights[key] = f
+		sent := *req
+		go func() {
+			f.resp, f.err = fn(fctx, &sent)
+			d.mu.Lock()
+			if d.flights[key] == f {
+				delete(d.flights, key)
+			}
+			d.mu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	d.mu.Unlock()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		d.mu.Lock()
+		if f.waiters--; f.waiters == 0 {
+			// Nobody is left to receive the response.
+			if d.flights[key] == f {
+				delete(d.flights, key)
+			}
+			f.cancel()
+		}
+		d.mu.Unlock()
+		return nil, ctx.Err()
+	}
+	if f.resp == nil {
+		return nil, f.err
+	}
+	shared := *f.resp
+	shared.ID = req.ID
+	return &shared, f.err
+}
+
+// dedupeKey identifies calls with the same method and params.
+func (c *rpcClient) dedupeKey(req *RPCRequest) (string, error) {
+	params, err := c.json.Marshal(req.Params)
+	if err != nil {
+		return "", err
+	}
+	return req.Method + "\x00" + string(params), nil
+}
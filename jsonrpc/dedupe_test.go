@@ -0,0 +1,81 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// echoClient answers each call with its method name as result.
+type echoClient struct{}
+
+func (echoClient) Do(req *http.Request) (*http.Response, error) {
+	var call struct {
+		Method string `json:"method"`
+		ID     int    `json:"id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&call); err != nil {
+		return nil, err
+	}
+	body := fmt.Sprintf(`{"id":%d,"result":%q}`, call.ID, call.Method)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		Request:    req,
+	}, nil
+}
+
+func TestDedupeOutlivesFirstCaller(t *testing.T) {
+	c := NewClientWithOpts("http://rpc.invalid/", &RPCClientOpts{
+		HTTPClient:  echoClient{},
+		DedupeCalls: true,
+		// Hold the shared call of "slow" in the rate limiter, before its
+		// request is encoded.
+		MethodRateLimits: map[string]RateLimit{"slow": {PerSecond: 10, Burst: 1}},
+	})
+	if _, err := c.Call(context.Background(), "slow"); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		resp *RPCResponse
+		err  error
+	}
+	first, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := c.Call(first, "slow")
+		firstDone <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	second := make(chan result, 1)
+	go func() {
+		resp, err := c.Call(context.Background(), "slow")
+		second <- result{resp, err}
+	}()
+	// Let the second caller join the flight before the first leaves it.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-firstDone; err == nil {
+		t.Fatal("canceled caller got no error")
+	}
+
+	// Reuse the pooled request the first caller released.
+	if resp, err := c.Call(context.Background(), "other"); err != nil || resp.Result != "other" {
+		t.Fatalf("other() = %v, %v", resp, err)
+	}
+
+	got := <-second
+	if got.err != nil {
+		t.Fatalf("waiting caller failed: %v", got.err)
+	}
+	if got.resp.Result != "slow" {
+		t.Errorf("waiting caller got %v, want slow", got.resp.Result)
+	}
+}
@@ -0,0 +1,37 @@
+package jsonrpc
+
+// RequestEncoderFunc shapes a request before it is marshaled. It returns the
+// value to encode in place of req, e.g. a map with vendor extension members
+// or a json.RawMessage for full control over field order.
+type RequestEncoderFunc func(req *RPCRequest) (any, error)
+
+// shapeRequest applies the request encoder hook to req, if one is set.
+func (c *rpcClient) shapeRequest(req *RPCRequest) (any, error) {
+	if c.requestEncoder == nil {
+		return req, nil
+	}
+	return c.requestEncoder(req)
+}
+
+// shapePayload applies the request encoder hook to a single request or to
+// every element of a batch.
+func (c *rpcClient) shapePayload(payload any) (any, error) {
+	if c.requestEncoder == nil {
+		return payload, nil
+	}
+	switch p := payload.(type) {
+	case *RPCRequest:
+		return c.shapeRequest(p)
+	case []*RPCRequest:
+		out := make([]any, len(p))
+		for i, req := range p {
+			v, err := c.shapeRequest(req)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	}
+	return payload, nil
+}
@@ -0,0 +1,57 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONEngine abstracts the JSON implementation used to encode requests and decode responses.
+// Adapters for goccy/go-json, jsoniter or sonic only need to forward to the library's own
+// functions of the same name. Implementations must be comparable, e.g. an empty struct.
+type JSONEngine interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	NewEncoder(w io.Writer) JSONEncoder
+	NewDecoder(r io.Reader) JSONDecoder
+}
+
+// JSONEncoder writes JSON values to a stream. Encoders that also implement
+// SetEscapeHTML(bool) and SetIndent(prefix, indent string) honor
+// RPCClientOpts.DisableHTMLEscape and DebugOpts.Indent.
+type JSONEncoder interface {
+	Encode(v any) error
+}
+
+// JSONDecoder reads JSON values from a stream.
+type JSONDecoder interface {
+	Decode(v any) error
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+// JSONTokenDecoder is implemented by decoders that can walk a stream token by token.
+// Batch responses are decoded element by element only when the decoder supports it.
+type JSONTokenDecoder interface {
+	JSONDecoder
+	Token() (json.Token, error)
+	More() bool
+}
+
+// StdJSON is the encoding/json engine used by default.
+var StdJSON JSONEngine = stdJSON{}
+
+// stdJSON implements JSONEngine with encoding/json.
+type stdJSON struct{}
+
+func (stdJSON) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (stdJSON) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (stdJSON) NewEncoder(w io.Writer) JSONEncoder { return json.NewEncoder(w) }
+func (stdJSON) NewDecoder(r io.Reader) JSONDecoder { return json.NewDecoder(r) }
+
+// engineOrDefault returns e, or StdJSON when e is nil.
+func engineOrDefault(e JSONEngine) JSONEngine {
+	if e == nil {
+		return StdJSON
+	}
+	return e
+}
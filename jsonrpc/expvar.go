@@ -0,0 +1,84 @@
+package jsonrpc
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// expvarMu serializes lookup-or-publish of expvar maps.
+var expvarMu sync.Mutex
+
+// expvarCounters publishes call counters under a common expvar prefix.
+type expvarCounters struct {
+	root     *expvar.Map
+	requests *expvar.Int
+	errors   *expvar.Int
+	latency  *expvar.Int
+	methods  *expvar.Map
+}
+
+// publishInFlight exposes the limiter's active and queued counts as gauges.
+func (e *expvarCounters) publishInFlight(c *rpcClient) {
+	e.root.Set("in_flight", expvar.Func(func() any { active, _ := c.InFlight(); return active }))
+	e.root.Set("queue_depth", expvar.Func(func() any { _, queued := c.InFlight(); return queued }))
+}
+
+// newExpvarCounters publishes (or reuses) the expvar map named prefix.
+func newExpvarCounters(prefix string) *expvarCounters {
+	root := publishedMap(prefix)
+	return &expvarCounters{
+		root:     root,
+		requests: mapInt(root, "requests"),
+		errors:   mapInt(root, "errors"),
+		latency:  mapInt(root, "latency_ns"),
+		methods:  mapMap(root, "methods"),
+	}
+}
+
+// record adds one call outcome for method.
+func (e *expvarCounters) record(method string, d time.Duration, failed bool) {
+	e.requests.Add(1)
+	e.latency.Add(int64(d))
+	m := mapMap(e.methods, method)
+	m.Add("requests", 1)
+	m.Add("latency_ns", int64(d))
+	if failed {
+		e.errors.Add(1)
+		m.Add("errors", 1)
+	}
+}
+
+// publishedMap returns the top-level expvar map called name, publishing it if needed.
+func publishedMap(name string) *expvar.Map {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if m, ok := expvar.Get(name).(*expvar.Map); ok {
+		return m
+	}
+	return expvar.NewMap(name)
+}
+
+// mapInt returns the *expvar.Int stored under key, creating it if needed.
+func mapInt(m *expvar.Map, key string) *expvar.Int {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if v, ok := m.Get(key).(*expvar.Int); ok {
+		return v
+	}
+	v := new(expvar.Int)
+	m.Set(key, v)
+	return v
+}
+
+// mapMap returns the nested *expvar.Map stored under key, creating it if needed.
+func mapMap(m *expvar.Map, key string) *expvar.Map {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if v, ok := m.Get(key).(*expvar.Map); ok {
+		return v
+	}
+	v := new(expvar.Map).Init()
+	m.Set(key, v)
+	return v
+}
@@ -0,0 +1,56 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// GetEncoding selects how the params of GET-encoded calls appear in the query.
+type GetEncoding int
+
+const (
+	// GetEncodingBase64 sends params as unpadded base64url-encoded JSON.
+	GetEncodingBase64 GetEncoding = iota
+	// GetEncodingQuery sends params as percent-encoded JSON.
+	GetEncodingQuery
+)
+
+// useGet reports whether method is sent as an HTTP GET.
+func (c *rpcClient) useGet(method string) bool {
+	return len(c.getMethods) > 0 && slices.Contains(c.getMethods, method)
+}
+
+// getRequestID is the id sent with every GET-encoded call. Per-call ids
+// would make every URL unique and defeat HTTP caching, so the single
+// response is matched to the call by position instead.
+const getRequestID = 1
+
+// newGetRequest encodes req into the query string of a GET request, e.g.
+// ?method=eth_chainId&id=1&params=WzFd, so responses can be cached by HTTP
+// caches and CDNs.
+func (c *rpcClient) newGetRequest(ctx context.Context, req *RPCRequest) (*http.Request, error) {
+	u, err := url.Parse(c.currentEndpoint())
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("method", req.Method)
+	q.Set("id", strconv.Itoa(getRequestID))
+	if req.Params != nil {
+		params, err := c.json.Marshal(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		if c.getEncoding == GetEncodingBase64 {
+			q.Set("params", base64.RawURLEncoding.EncodeToString(params))
+		} else {
+			q.Set("params", string(params))
+		}
+	}
+	u.RawQuery = q.Encode()
+	return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+}
@@ -0,0 +1,87 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxErrorSnippet caps the body excerpt kept on non-JSON error responses.
+const maxErrorSnippet = 512
+
+// HTTPInfo carries details of the HTTP response an RPC response arrived in,
+// such as rate-limit headers or request IDs set outside the JSON-RPC envelope.
+type HTTPInfo struct {
+	StatusCode int
+	Header     http.Header
+	// Body holds the raw response body when RPCClientOpts.KeepRawBody is set.
+	Body []byte
+}
+
+// responseDecoder prepares a decoder for httpResp along with its HTTPInfo and
+// the first non-space byte of the body, or zero for an empty body. The
+// decoder reads through a pooled buffer: call release once it is done.
+func (c *rpcClient) responseDecoder(httpResp *http.Response) (dec JSONDecoder, info *HTTPInfo, lead byte, release func(), err error) {
+	info = &HTTPInfo{StatusCode: httpResp.StatusCode, Header: c.selectHeaders(httpResp.Header)}
+	var body io.Reader = httpResp.Body
+	if c.keepRawBody {
+		raw, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return nil, nil, 0, nil, err
+		}
+		info.Body = raw
+		body = bytes.NewReader(raw)
+	}
+	br := acquireBodyReader(body)
+	lead, err = checkJSONBody(httpResp, br)
+	if err != nil {
+		releaseBodyReader(br)
+		return nil, nil, 0, nil, err
+	}
+	dec = c.json.NewDecoder(br)
+	if !c.allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	return dec, info, lead, func() { releaseBodyReader(br) }, nil
+}
+
+// selectHeaders returns the response headers to expose: all of them unless
+// RPCClientOpts.ResponseHeaders names a subset.
+func (c *rpcClient) selectHeaders(h http.Header) http.Header {
+	if c.responseHeaders == nil {
+		return h
+	}
+	out := make(http.Header, len(c.responseHeaders))
+	for _, name := range c.responseHeaders {
+		if vs := h.Values(name); len(vs) > 0 {
+			out[http.CanonicalHeaderKey(name)] = vs
+		}
+	}
+	return out
+}
+
+// checkJSONBody peeks at br and returns an *HTTPError when it does not start
+// like a JSON-RPC payload, e.g. an HTML 502 page from a load balancer.
+// It also returns the first non-space byte of the body.
+func checkJSONBody(httpResp *http.Response, br *bufio.Reader) (byte, error) {
+	peek, _ := br.Peek(maxErrorSnippet)
+	trimmed := bytes.TrimLeft(peek, " \t\r\n")
+	if len(trimmed) == 0 && httpResp.StatusCode < 400 {
+		return 0, nil
+	}
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[' || bytes.HasPrefix(trimmed, []byte("null"))) {
+		return trimmed[0], nil
+	}
+	contentType := httpResp.Header.Get("Content-Type")
+	snippet := strings.ToValidUTF8(string(peek), string(utf8.RuneError))
+	return 0, &HTTPError{
+		Code:        httpResp.StatusCode,
+		ContentType: contentType,
+		Body:        snippet,
+		err:         fmt.Errorf("unexpected non-JSON response (status %d, %s): %q", httpResp.StatusCode, contentType, snippet),
+	}
+}
@@ -0,0 +1,46 @@
+package jsonrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// IdempotencyKeyHeader is the header carrying a call's idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey sends key as the call's Idempotency-Key header instead of
+// a generated one. It takes effect even when RPCClientOpts.IdempotencyKeys is off.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) { o.idempotencyKey = key }
+}
+
+// withIdempotencyKey attaches a fresh key to ctx when keys are generated
+// automatically and the caller did not supply one. The key lives on the
+// context so every retry of the logical call reuses it.
+func (c *rpcClient) withIdempotencyKey(ctx context.Context) context.Context {
+	if !c.idempotencyKeys || callOptionsFrom(ctx).idempotencyKey != "" {
+		return ctx
+	}
+	return WithCallOptions(ctx, WithIdempotencyKey(newUUID()))
+}
+
+// withRetryIdempotencyKey derives the key of the attempt-th re-send of part
+// of a batch from the batch's key, so the server does not answer the smaller
+// batch with the response it stored for the whole one.
+func withRetryIdempotencyKey(ctx context.Context, attempt int) context.Context {
+	key := callOptionsFrom(ctx).idempotencyKey
+	if key == "" {
+		return ctx
+	}
+	return WithCallOptions(ctx, WithIdempotencyKey(fmt.Sprintf("%s-retry-%d", key, attempt)))
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
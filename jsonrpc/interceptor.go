@@ -0,0 +1,103 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Side tells an Interceptor which end of a call it runs on.
+type Side string
+
+// Sides of a call.
+const (
+	ClientSide Side = "client"
+	ServerSide Side = "server"
+)
+
+// CallInfo describes the call passing through an interceptor chain.
+type CallInfo struct {
+	Method string
+	Side   Side
+	// Header holds the outgoing request headers on the client, which
+	// interceptors may add to, and the incoming request headers on the
+	// server, which they should treat as read-only.
+	Header http.Header
+}
+
+// Invoker continues a call: it sends the request on the client and runs
+// the handler on the server. On the client the result is the
+// *RPCResponse and RPC errors are returned as *RPCError; on the server
+// params is the raw json.RawMessage and the result is the handler's.
+type Invoker func(ctx context.Context, params any) (any, error)
+
+// Interceptor wraps a unary call on either side, so tracing, auth
+// propagation or metrics can be written once and installed with
+// RPCClientOpts.Interceptors and jsonrpcserver.Intercept. It must call next
+// to continue the call.
+type Interceptor func(ctx context.Context, info *CallInfo, params any, next Invoker) (any, error)
+
+// ChainInterceptors combines interceptors into one; the first is the
+// outermost.
+func ChainInterceptors(ics ...Interceptor) Interceptor {
+	return func(ctx context.Context, info *CallInfo, params any, next Invoker) (any, error) {
+		for i := len(ics) - 1; i >= 0; i-- {
+			ic, inner := ics[i], next
+			next = func(ctx context.Context, params any) (any, error) {
+				return ic(ctx, info, params, inner)
+			}
+		}
+		return next(ctx, params)
+	}
+}
+
+// callInfoKey is the context key under which the CallInfo of a client call
+// is stored.
+type callInfoKey struct{}
+
+// CallInfoFromContext returns the CallInfo of the intercepted call under
+// ctx, or nil.
+func CallInfoFromContext(ctx context.Context) *CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(*CallInfo)
+	return info
+}
+
+// WithCallInfo returns a copy of ctx carrying info.
+func WithCallInfo(ctx context.Context, info *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// intercept runs req through the client interceptors before invoking it.
+// RPC errors are returned alongside the response.
+func (c *rpcClient) intercept(ctx context.Context, req *RPCRequest, send func(context.Context, *RPCRequest) (*RPCResponse, error)) (*RPCResponse, error) {
+	if c.interceptor == nil {
+		return send(ctx, req)
+	}
+	info := &CallInfo{Method: req.Method, Side: ClientSide, Header: make(http.Header)}
+	out, err := c.interceptor(WithCallInfo(ctx, info), info, req.Params, func(ctx context.Context, params any) (any, error) {
+		req.Params = params
+		resp, err := send(ctx, req)
+		if err == nil && resp != nil && resp.Error != nil {
+			return resp, resp.Error
+		}
+		return resp, err
+	})
+	resp, _ := out.(*RPCResponse)
+	var rpcErr *RPCError
+	if resp != nil && errors.As(err, &rpcErr) && rpcErr == resp.Error {
+		return resp, nil
+	}
+	return resp, err
+}
+
+// applyCallHeaders sets the headers added by interceptors of the call
+// under ctx.
+func applyCallHeaders(ctx context.Context, httpReq *http.Request) {
+	info := CallInfoFromContext(ctx)
+	if info == nil || info.Side != ClientSide {
+		return
+	}
+	for k, v := range info.Header {
+		httpReq.Header[k] = v
+	}
+}
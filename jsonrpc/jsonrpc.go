@@ -6,10 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"maps"
 	"net/http"
 	"reflect"
+	"runtime"
+	"slices"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -20,6 +24,7 @@ const (
 	ErrMethodNotFound   = -32601
 	ErrInvalidParams    = -32602
 	ErrInternalError    = -32603
+	ErrLimitExceeded    = -32005
 )
 
 // RPCClient defines methods for making JSON-RPC calls.
@@ -29,6 +34,9 @@ type RPCClient interface {
 	CallFor(ctx context.Context, out any, method string, params ...any) error
 	CallBatch(ctx context.Context, requests RPCRequests) (RPCResponses, error)
 	CallBatchRaw(ctx context.Context, requests RPCRequests) (RPCResponses, error)
+	CallBatchStream(ctx context.Context, requests RPCRequests, handle func(*RPCResponse) error) error
+	Stats() map[string]MethodStats
+	InFlight() (active, queued int)
 }
 
 // RPCRequest represents a JSON-RPC request.
@@ -53,6 +61,42 @@ type RPCResponse struct {
 	Result any       `json:"result,omitempty"`
 	Error  *RPCError `json:"error,omitempty"`
 	ID     int       `json:"id"`
+
+	// HTTP describes the HTTP response this response was received in.
+	HTTP *HTTPInfo `json:"-"`
+
+	// rawResult holds the undecoded result bytes as received.
+	rawResult json.RawMessage
+	// engine decodes rawResult; nil means StdJSON.
+	engine JSONEngine
+	// codec tells GetObject how times and big numbers in the result are encoded.
+	codec wireCodec
+}
+
+// wireResponse is the decoding shape of an RPCResponse that keeps the raw result.
+type wireResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RPCError       `json:"error,omitempty"`
+	ID     int             `json:"id"`
+}
+
+// toResponse decodes the raw result and builds the public response.
+func (w *wireResponse) toResponse(engine JSONEngine) (*RPCResponse, error) {
+	if w == nil {
+		return nil, nil
+	}
+	resp := AcquireResponse()
+	resp.Error, resp.ID, resp.rawResult, resp.engine = w.Error, w.ID, w.Result, engine
+	if len(w.Result) == 0 {
+		return resp, nil
+	}
+	dec := engine.NewDecoder(bytes.NewReader(w.Result))
+	dec.UseNumber()
+	if err := dec.Decode(&resp.Result); err != nil {
+		ReleaseResponse(resp)
+		return nil, err
+	}
+	return resp, nil
 }
 
 // RPCError represents a JSON-RPC error.
@@ -70,7 +114,11 @@ func (e *RPCError) Error() string {
 // HTTPError represents an HTTP-level error.
 type HTTPError struct {
 	Code int
-	err  error
+	// ContentType and Body are set when the server answered with a non-JSON
+	// payload; Body holds a truncated excerpt.
+	ContentType string
+	Body        string
+	err         error
 }
 
 // Error implements the error interface for HTTPError.
@@ -84,10 +132,52 @@ type HTTPClient interface {
 // rpcClient implements RPCClient using HTTP transport.
 type rpcClient struct {
 	endpoint           string
+	rewrittenEndpoint  atomic.Pointer[string]
+	redirectPolicy     RedirectPolicy
+	redirectCrossHost  bool
+	endpoints          *endpointSet
+	idempotencyKeys    bool
+	getMethods         []string
+	getEncoding        GetEncoding
+	paramsMode         ParamsMode
+	timeEncoding       TimeEncoding
+	bigNumbers         BigNumberEncoding
+	binary             BinaryEncoding
+	requestEncoder     RequestEncoderFunc
+	encodeFormat       encodeFormat
+	schemas            *methodSchemas
+	resultValidation   ResultValidationMode
+	onResultViolation  func(*ValidationError)
 	httpClient         HTTPClient
 	customHeaders      map[string]string
+	headersMu          sync.RWMutex
+	reauth             *reauthenticator
 	allowUnknownFields bool
 	requestIDCounter   int64
+	debug              *DebugOpts
+	json               JSONEngine
+	maxBatchSize       int
+	maxBatchBytes      int
+	batchParallelism   int
+	batchRetry         *BatchRetryPolicy
+	onBatchProgress    func(BatchProgress)
+	keepBatchIDs       bool
+	coalescer          *coalescer
+	deduper            *deduper
+	limiter            *inFlightLimiter
+	rateLimiter        *rateLimiter
+	timeout            time.Duration
+	acceptGzip         bool
+	gzipMinBytes       int
+	responseHeaders    []string
+	keepRawBody        bool
+	stats              *statsRecorder
+	slowCalls          *slowCallDetector
+	expvars            *expvarCounters
+	profilerLabels     bool
+	logger             *slog.Logger
+	logSampler         *logSampler
+	interceptor        Interceptor
 }
 
 // RPCClientOpts contains options for creating an RPC client.
@@ -97,6 +187,57 @@ type RPCClientOpts struct {
 	AllowUnknownFields bool
 	DefaultRequestID   int
 	Timeout            time.Duration
+	Debug              *DebugOpts
+	EnableStats        bool
+	StatsWindow        int
+	SlowCallThreshold  time.Duration
+	SlowCallThresholds map[string]time.Duration
+	OnSlowCall         func(SlowCall)
+	ExpvarPrefix       string
+	ProfilerLabels     bool
+	Logger             *slog.Logger
+	LogSampling        *LogSampling
+	JSONEngine         JSONEngine
+	MaxBatchSize       int
+	MaxBatchBytes      int
+	BatchParallelism   int
+	BatchRetry         *BatchRetryPolicy
+	OnBatchProgress    func(BatchProgress)
+	KeepBatchIDs       bool
+	CoalesceWindow     time.Duration
+	CoalesceMaxSize    int
+	DedupeCalls        bool
+	DedupeMethods      []string
+	MaxInFlight        int
+	InFlightFailFast   bool
+	RateLimit          *RateLimit
+	MethodRateLimits   map[string]RateLimit
+	RateLimitReject    bool
+	AcceptGzip         bool
+	GzipMinBytes       int
+	ResponseHeaders    []string
+	KeepRawBody        bool
+	ReAuth             ReAuthFunc
+	RedirectPolicy     RedirectPolicy
+	RedirectCrossHost  bool
+	IdempotencyKeys    bool
+	GetMethods         []string
+	GetEncoding        GetEncoding
+	Transport          *TransportOpts
+	Resolver           Resolver
+	ResolveInterval    time.Duration
+	ParamsMode         ParamsMode
+	TimeEncoding       TimeEncoding
+	BigNumbers         BigNumberEncoding
+	BinaryEncoding     BinaryEncoding
+	RequestEncoder     RequestEncoderFunc
+	DisableHTMLEscape  bool
+	ParamSchemas       map[string]*Schema
+	ResultSchemas      map[string]*Schema
+	OpenRPCMethods     []OpenRPCMethod
+	ResultValidation   ResultValidationMode
+	OnResultViolation  func(*ValidationError)
+	Interceptors       []Interceptor
 }
 
 // RPCResponses is a slice of RPC responses with helper methods.
@@ -109,7 +250,9 @@ type RPCRequests []*RPCRequest
 func (res RPCResponses) AsMap() map[int]*RPCResponse {
 	m := make(map[int]*RPCResponse, len(res))
 	for _, r := range res {
-		m[r.ID] = r
+		if r != nil {
+			m[r.ID] = r
+		}
 	}
 	return m
 }
@@ -134,6 +277,97 @@ func (res RPCResponses) HasError() bool {
 	return false
 }
 
+// Errors joins the RPC errors of all failed responses, each prefixed with
+// its ID. It returns nil when no response failed.
+func (res RPCResponses) Errors() error {
+	var errs []error
+	for _, r := range res {
+		if r != nil && r.Error != nil {
+			errs = append(errs, fmt.Errorf("id %d: %w", r.ID, r.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FirstError returns the RPC error of the first failed response, or nil.
+func (res RPCResponses) FirstError() *RPCError {
+	for _, r := range res {
+		if r != nil && r.Error != nil {
+			return r.Error
+		}
+	}
+	return nil
+}
+
+// FailedIDs returns the IDs of responses that carry an error.
+func (res RPCResponses) FailedIDs() []int {
+	var ids []int
+	for _, r := range res {
+		if r != nil && r.Error != nil {
+			ids = append(ids, r.ID)
+		}
+	}
+	return ids
+}
+
+// DecodeInto unmarshals the result of each response into the target stored
+// under its ID. The returned map holds an error for every ID whose response
+// is missing, failed or could not be decoded; it is nil when all succeeded.
+func (res RPCResponses) DecodeInto(targets map[int]any) map[int]error {
+	return res.DecodeIntoParallel(targets, 1)
+}
+
+// DecodeIntoParallel is DecodeInto spread over up to parallelism workers,
+// for bulk fetches where unmarshaling large results dominates latency.
+// A parallelism below one uses runtime.GOMAXPROCS.
+func (res RPCResponses) DecodeIntoParallel(targets map[int]any, parallelism int) map[int]error {
+	if parallelism < 1 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	byID := res.AsMap()
+	type job struct {
+		id int
+		to any
+	}
+	jobs := make(chan job)
+	var (
+		mu   sync.Mutex
+		errs map[int]error
+		wg   sync.WaitGroup
+	)
+	for range min(parallelism, max(len(targets), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				var err error
+				switch r := byID[j.id]; {
+				case r == nil:
+					err = ErrNoResponse
+				case r.Error != nil:
+					err = r.Error
+				default:
+					err = r.GetObject(j.to)
+				}
+				if err != nil {
+					mu.Lock()
+					if errs == nil {
+						errs = make(map[int]error)
+					}
+					errs[j.id] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for id, to := range targets {
+		jobs <- job{id, to}
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
 // NewClient creates an RPCClient with default options.
 func NewClient(endpoint string) RPCClient {
 	return NewClientWithOpts(endpoint, nil)
@@ -146,45 +380,139 @@ func NewClientWithOpts(endpoint string, opts *RPCClientOpts) RPCClient {
 		endpoint:      endpoint,
 		httpClient:    httpClient,
 		customHeaders: make(map[string]string),
+		json:          StdJSON,
 	}
 	if opts == nil {
 		return c
 	}
 	if opts.HTTPClient != nil {
 		c.httpClient = opts.HTTPClient
+	} else if opts.Transport != nil {
+		httpClient.Transport = newTransport(opts.Transport)
 	}
 	if opts.CustomHeaders != nil {
 		maps.Copy(c.customHeaders, opts.CustomHeaders)
 	}
 	c.allowUnknownFields = opts.AllowUnknownFields
 	c.requestIDCounter = int64(opts.DefaultRequestID)
+	c.debug = opts.Debug
+	c.json = engineOrDefault(opts.JSONEngine)
+	c.maxBatchSize = opts.MaxBatchSize
+	c.maxBatchBytes = opts.MaxBatchBytes
+	c.batchParallelism = opts.BatchParallelism
+	c.batchRetry = opts.BatchRetry
+	c.onBatchProgress = opts.OnBatchProgress
+	c.keepBatchIDs = opts.KeepBatchIDs
+	c.coalescer = newCoalescer(c, opts.CoalesceWindow, opts.CoalesceMaxSize)
+	c.deduper = newDeduper(opts.DedupeCalls, opts.DedupeMethods)
+	c.limiter = newInFlightLimiter(opts.MaxInFlight, opts.InFlightFailFast)
+	c.rateLimiter = newRateLimiter(opts.RateLimit, opts.MethodRateLimits, opts.RateLimitReject)
+	if opts.EnableStats {
+		c.stats = newStatsRecorder(opts.StatsWindow)
+	}
+	c.slowCalls = newSlowCallDetector(opts)
+	c.profilerLabels = opts.ProfilerLabels
+	c.logger = opts.Logger
+	c.logSampler = newLogSampler(opts.LogSampling)
+	if opts.ExpvarPrefix != "" {
+		c.expvars = newExpvarCounters(opts.ExpvarPrefix)
+	}
+	if c.expvars != nil && c.limiter != nil {
+		c.expvars.publishInFlight(c)
+	}
+	c.timeout = opts.Timeout
+	c.acceptGzip = opts.AcceptGzip
+	c.gzipMinBytes = opts.GzipMinBytes
+	c.responseHeaders = opts.ResponseHeaders
+	c.keepRawBody = opts.KeepRawBody
+	c.reauth = newReauthenticator(opts.ReAuth)
+	c.redirectPolicy = opts.RedirectPolicy
+	c.redirectCrossHost = opts.RedirectCrossHost
+	c.endpoints = newEndpointSet(opts.Resolver, opts.ResolveInterval)
+	c.idempotencyKeys = opts.IdempotencyKeys
+	c.getMethods = slices.Clone(opts.GetMethods)
+	c.getEncoding = opts.GetEncoding
+	c.paramsMode = opts.ParamsMode
+	c.timeEncoding = opts.TimeEncoding
+	c.bigNumbers = opts.BigNumbers
+	c.binary = opts.BinaryEncoding
+	c.requestEncoder = opts.RequestEncoder
+	c.encodeFormat.noEscapeHTML = opts.DisableHTMLEscape
+	if opts.Debug != nil {
+		c.encodeFormat.indent = opts.Debug.Indent
+	}
+	c.schemas = newMethodSchemas(maps.Clone(opts.ParamSchemas), maps.Clone(opts.ResultSchemas), slices.Clone(opts.OpenRPCMethods))
+	c.resultValidation = opts.ResultValidation
+	if len(opts.Interceptors) > 0 {
+		c.interceptor = ChainInterceptors(slices.Clone(opts.Interceptors)...)
+	}
+	c.onResultViolation = opts.OnResultViolation
 	if opts.Timeout > 0 {
 		httpClient.Timeout = opts.Timeout
 	}
+	if c.redirectPolicy != RedirectDefault {
+		c.httpClient = noFollowClient(c.httpClient)
+	}
 	return c
 }
 
 // Call makes an RPC call and returns RPC errors as Go errors.
 func (c *rpcClient) Call(ctx context.Context, method string, params ...any) (*RPCResponse, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	p, err := c.buildParams(ctx, params)
+	if err == nil {
+		p, err = c.encodeParams(p, c.codecFor(ctx))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rpc call %v() on %v: %w", method, c.endpoint, err)
+	}
 	id := atomic.AddInt64(&c.requestIDCounter, 1)
-	req := &RPCRequest{
-		ID:     int(id),
-		Method: method,
-		Params: Params(params...),
+	req := AcquireRequest()
+	defer ReleaseRequest(req)
+	req.ID, req.Method, req.Params = int(id), method, p
+	if err := c.validateParams(req); err != nil {
+		return nil, err
 	}
-	resp, err := c.doCall(ctx, req)
+	resp, err := c.intercept(ctx, req, c.invoke)
 	if err != nil {
 		return nil, err
 	}
+	if resp != nil {
+		resp.codec = c.codecFor(ctx)
+	}
 	if resp != nil && resp.Error != nil {
 		return resp, resp.Error
 	}
+	if err := c.validateResult(method, resp); err != nil {
+		return resp, err
+	}
 	return resp, nil
 }
 
+// invoke routes a call through deduplication and coalescing before sending it.
+func (c *rpcClient) invoke(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	send := func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		if c.coalescer != nil {
+			return c.coalescer.call(ctx, req)
+		}
+		return c.doCall(ctx, req)
+	}
+	if !c.deduper.enabled(req.Method) {
+		return send(ctx, req)
+	}
+	key, err := c.dedupeKey(req)
+	if err != nil {
+		return nil, fmt.Errorf("rpc call %v() on %v: %w", req.Method, c.endpoint, err)
+	}
+	return c.deduper.do(ctx, key, req, send)
+}
+
 // CallRaw makes an RPC call without modification to the request.
 func (c *rpcClient) CallRaw(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
-	return c.doCall(ctx, req)
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	return c.intercept(ctx, req, c.doCall)
 }
 
 // CallFor makes an RPC call and unmarshals the result into out.
@@ -198,15 +526,48 @@ func (c *rpcClient) CallFor(ctx context.Context, out any, method string, params
 }
 
 // CallBatch makes multiple RPC calls in a single batch request.
+// Request IDs are reassigned from the client counter unless
+// RPCClientOpts.KeepBatchIDs is set.
 func (c *rpcClient) CallBatch(ctx context.Context, requests RPCRequests) (RPCResponses, error) {
 	if len(requests) == 0 {
 		return nil, errors.New("empty request list")
 	}
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	codec := c.codecFor(ctx)
 	for i := range requests {
-		id := atomic.AddInt64(&c.requestIDCounter, 1)
-		requests[i].ID = int(id)
+		if !c.keepBatchIDs {
+			id := atomic.AddInt64(&c.requestIDCounter, 1)
+			requests[i].ID = int(id)
+		}
+		p, err := c.encodeParams(requests[i].Params, codec)
+		if err != nil {
+			return nil, fmt.Errorf("rpc call %v() on %v: %w", requests[i].Method, c.endpoint, err)
+		}
+		requests[i].Params = p
+		if err := c.validateParams(requests[i]); err != nil {
+			return nil, err
+		}
+	}
+	resps, err := c.doBatchCall(ctx, requests)
+	if err == nil {
+		resps, err = c.retryBatch(ctx, requests, resps)
+	}
+	methods := make(map[int]string, len(requests))
+	for _, req := range requests {
+		methods[req.ID] = req.Method
+	}
+	var verrs []error
+	for _, r := range resps {
+		if r != nil {
+			r.codec = codec
+			verrs = append(verrs, c.validateResult(methods[r.ID], r))
+		}
 	}
-	return c.doBatchCall(ctx, requests)
+	if err == nil {
+		err = errors.Join(verrs...)
+	}
+	return resps, err
 }
 
 // CallBatchRaw makes a batch call without modifying request IDs.
@@ -214,87 +575,198 @@ func (c *rpcClient) CallBatchRaw(ctx context.Context, requests RPCRequests) (RPC
 	if len(requests) == 0 {
 		return nil, errors.New("empty request list")
 	}
-	return c.doBatchCall(ctx, requests)
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	resps, err := c.doBatchCall(ctx, requests)
+	if err != nil {
+		return resps, err
+	}
+	return c.retryBatch(ctx, requests, resps)
 }
 
-// newRequest creates an HTTP request with JSON-encoded body.
-func (c *rpcClient) newRequest(ctx context.Context, req any) (*http.Request, error) {
-	body, err := json.Marshal(req)
+// newRequest creates an HTTP request with JSON-encoded body, or a GET request
+// for methods listed in RPCClientOpts.GetMethods.
+// The returned release func must be called once the response is consumed.
+func (c *rpcClient) newRequest(ctx context.Context, req any) (*http.Request, func(), error) {
+	if r, ok := req.(*RPCRequest); ok && c.useGet(r.Method) {
+		httpReq, err := c.newGetRequest(ctx, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.setHeaders(ctx, httpReq)
+		return httpReq, func() {}, nil
+	}
+	payload, err := c.shapePayload(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(body))
+	eb := acquireEncodeBuffer(c.json, c.encodeFormat)
+	release := func() { releaseEncodeBuffer(eb) }
+	body, err := eb.encode(payload)
 	if err != nil {
-		return nil, err
+		release()
+		return nil, nil, err
+	}
+	body, gzipped, err := c.gzipBody(body)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.currentEndpoint(), bytes.NewReader(body))
+	if err != nil {
+		release()
+		return nil, nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if gzipped {
+		httpReq.Header.Set("Content-Encoding", "gzip")
+	}
+	c.setHeaders(ctx, httpReq)
+	return httpReq, release, nil
+}
+
+// setHeaders sets the headers shared by every request method.
+func (c *rpcClient) setHeaders(ctx context.Context, httpReq *http.Request) {
 	httpReq.Header.Set("Accept", "application/json")
-	for k, v := range c.customHeaders {
-		if k == "Host" {
-			httpReq.Host = v
-		} else {
-			httpReq.Header.Set(k, v)
-		}
+	if c.acceptGzip {
+		httpReq.Header.Set("Accept-Encoding", "gzip")
+	}
+	c.applyHeaders(httpReq)
+	applyCallHeaders(ctx, httpReq)
+	if key := callOptionsFrom(ctx).idempotencyKey; key != "" {
+		httpReq.Header.Set(IdempotencyKeyHeader, key)
 	}
-	return httpReq, nil
 }
 
-// doCall sends an RPC request and decodes the response.
+// doCall sends an RPC request and records its outcome.
 func (c *rpcClient) doCall(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
-	httpReq, err := c.newRequest(ctx, req)
+	if err := c.waitRate(ctx, req); err != nil {
+		return nil, fmt.Errorf("rpc call %v() on %v: %w", req.Method, c.endpoint, err)
+	}
+	var (
+		resp *RPCResponse
+		err  error
+	)
+	start := time.Now()
+	c.withProfilerLabels(ctx, req.Method, func(ctx context.Context) {
+		resp, err = c.sendCall(ctx, req)
+	})
+	c.observe(req, resp, err, time.Since(start))
+	return resp, err
+}
+
+// sendCall sends an RPC request and decodes the response.
+func (c *rpcClient) sendCall(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	httpReq, release, err := c.newRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("rpc call %v() on %v: %w", req.Method, c.endpoint, err)
 	}
-	httpResp, err := c.httpClient.Do(httpReq)
+	defer release()
+	httpResp, err := c.doHTTP(httpReq, req.Method)
 	if err != nil {
 		return nil, fmt.Errorf("rpc call %v() on %v: %w", req.Method, httpReq.URL.Redacted(), err)
 	}
 	defer httpResp.Body.Close()
 
-	var resp *RPCResponse
-	dec := json.NewDecoder(httpResp.Body)
-	if !c.allowUnknownFields {
-		dec.DisallowUnknownFields()
+	dec, info, _, releaseBody, err := c.responseDecoder(httpResp)
+	if err != nil {
+		return nil, fmt.Errorf("rpc call %v() on %v: %w", req.Method, httpReq.URL.Redacted(), err)
 	}
-	dec.UseNumber()
-	err = dec.Decode(&resp)
+	defer releaseBody()
+	resp, err := c.decodeResponse(dec)
 	if err != nil {
 		return nil, fmt.Errorf("rpc call %v() decode error: %w", req.Method, err)
 	}
+	if resp != nil {
+		resp.HTTP = info
+		if httpReq.Method == http.MethodGet {
+			resp.ID = req.ID
+		}
+	}
 	if httpResp.StatusCode >= 400 {
 		return resp, &HTTPError{Code: httpResp.StatusCode, err: fmt.Errorf("rpc error status %v", httpResp.StatusCode)}
 	}
 	return resp, nil
 }
 
-// doBatchCall sends multiple RPC requests and decodes responses.
+// doBatchCall sends multiple RPC requests and records their outcomes.
 func (c *rpcClient) doBatchCall(ctx context.Context, reqs []*RPCRequest) ([]*RPCResponse, error) {
-	httpReq, err := c.newRequest(ctx, reqs)
-	if err != nil {
+	if err := c.waitRate(ctx, reqs...); err != nil {
 		return nil, err
 	}
-	httpResp, err := c.httpClient.Do(httpReq)
-	if err != nil {
+	var (
+		resps RPCResponses
+		err   error
+	)
+	start := time.Now()
+	ctx = c.withBatchProgress(ctx, reqs)
+	c.withProfilerLabels(ctx, "batch", func(ctx context.Context) {
+		resps, err = c.sendChunkedBatch(ctx, reqs)
+	})
+	c.observeBatch(reqs, resps, err, time.Since(start))
+	return resps, err
+}
+
+// sendBatch sends multiple RPC requests and decodes responses.
+func (c *rpcClient) sendBatch(ctx context.Context, reqs []*RPCRequest) (RPCResponses, error) {
+	resps := make(RPCResponses, 0, len(reqs))
+	err := c.streamBatch(ctx, reqs, func(resp *RPCResponse) error {
+		resps = append(resps, resp)
+		return nil
+	})
+	var httpErr *HTTPError
+	if err != nil && !errors.As(err, &httpErr) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return resps, newPartialBatchError(reqs, resps, ctxErr)
+		}
 		return nil, err
 	}
+	return resps, err
+}
+
+// streamBatch sends multiple RPC requests and hands each response to fn as it is decoded.
+func (c *rpcClient) streamBatch(ctx context.Context, reqs []*RPCRequest, fn func(*RPCResponse) error) error {
+	httpReq, release, err := c.newRequest(ctx, reqs)
+	if err != nil {
+		return err
+	}
+	defer release()
+	httpResp, err := c.doHTTP(httpReq, "batch")
+	if err != nil {
+		return err
+	}
 	defer httpResp.Body.Close()
 
-	var resps RPCResponses
-	dec := json.NewDecoder(httpResp.Body)
-	if !c.allowUnknownFields {
-		dec.DisallowUnknownFields()
+	dec, info, lead, releaseBody, err := c.responseDecoder(httpResp)
+	if err != nil {
+		return err
 	}
-	dec.UseNumber()
-	if err := dec.Decode(&resps); err != nil {
-		return nil, fmt.Errorf("decode batch: %w", err)
+	defer releaseBody()
+	if lead == '{' {
+		return c.decodeBatchObject(dec, httpResp.StatusCode, info)
+	}
+	err = c.decodeBatch(dec, func(resp *RPCResponse) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if resp != nil {
+			resp.HTTP = info
+		}
+		reportProgress(ctx, resp)
+		return fn(resp)
+	})
+	if err != nil {
+		return fmt.Errorf("decode batch: %w", err)
 	}
 	if httpResp.StatusCode >= 400 {
-		return resps, &HTTPError{Code: httpResp.StatusCode, err: fmt.Errorf("rpc batch error %v", httpResp.StatusCode)}
+		return &HTTPError{Code: httpResp.StatusCode, err: fmt.Errorf("rpc batch error %v", httpResp.StatusCode)}
 	}
-	return resps, nil
+	return nil
 }
 
 // Params normalizes parameters into a single value or slice.
+// A single json.RawMessage is sent as-is, without re-encoding.
+// A struct with `rpc:"name,omitempty"` field tags becomes a named-params object.
 func Params(params ...any) any {
 	if len(params) == 0 {
 		return nil
@@ -308,7 +780,12 @@ func Params(params ...any) any {
 			return params
 		}
 		switch t.Kind() {
-		case reflect.Struct, reflect.Array, reflect.Slice, reflect.Interface, reflect.Map:
+		case reflect.Struct:
+			if named, ok := namedParams(params[0]); ok {
+				return named
+			}
+			return params[0]
+		case reflect.Array, reflect.Slice, reflect.Interface, reflect.Map:
 			return params[0]
 		default:
 			return params
@@ -353,11 +830,32 @@ func (r *RPCResponse) GetString() (string, error) {
 	return val, nil
 }
 
+// RawResult returns the result exactly as the server sent it, for callers
+// that forward or re-decode it without a lossy round trip.
+// Responses built by hand fall back to encoding Result.
+func (r *RPCResponse) RawResult() (json.RawMessage, error) {
+	if r.rawResult != nil {
+		return r.rawResult, nil
+	}
+	if r.Result == nil {
+		return nil, nil
+	}
+	return engineOrDefault(r.engine).Marshal(r.Result)
+}
+
 // GetObject unmarshals the response result into the target value.
 func (r *RPCResponse) GetObject(to any) error {
-	js, err := json.Marshal(r.Result)
+	engine := engineOrDefault(r.engine)
+	js := []byte(r.rawResult)
+	if js == nil {
+		var err error
+		if js, err = engine.Marshal(r.Result); err != nil {
+			return err
+		}
+	}
+	js, err := decodeResult(engine, js, to, r.codec)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(js, to)
+	return engine.Unmarshal(js, to)
 }
@@ -0,0 +1,59 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrTooManyInFlight is returned when MaxInFlight is reached and InFlightFailFast is set.
+var ErrTooManyInFlight = errors.New("too many requests in flight")
+
+// inFlightLimiter bounds the number of concurrent HTTP exchanges.
+type inFlightLimiter struct {
+	slots    chan struct{}
+	failFast bool
+	queued   atomic.Int64
+}
+
+// newInFlightLimiter returns nil when max is not positive.
+func newInFlightLimiter(max int, failFast bool) *inFlightLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &inFlightLimiter{slots: make(chan struct{}, max), failFast: failFast}
+}
+
+// acquire takes a slot, queueing until one frees up unless fail-fast is set.
+func (l *inFlightLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if l.failFast {
+		return ErrTooManyInFlight
+	}
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire.
+func (l *inFlightLimiter) release() {
+	<-l.slots
+}
+
+// InFlight reports the number of active and queued HTTP exchanges.
+// Both are zero when MaxInFlight is not set.
+func (c *rpcClient) InFlight() (active, queued int) {
+	if c.limiter == nil {
+		return 0, 0
+	}
+	return len(c.limiter.slots), int(c.limiter.queued.Load())
+}
@@ -0,0 +1,92 @@
+package jsonrpc
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// LogSampling limits how many calls are logged.
+type LogSampling struct {
+	// Every logs only the first of every N calls.
+	Every int
+	// PerSecond logs at most this many calls per second.
+	PerSecond int
+	// PerMethod applies the limits to each method separately.
+	PerMethod bool
+	// AlwaysLogErrors bypasses sampling for failed calls.
+	AlwaysLogErrors bool
+}
+
+// logSampler decides which calls are logged.
+type logSampler struct {
+	cfg     LogSampling
+	mu      sync.Mutex
+	buckets map[string]*sampleBucket
+}
+
+// sampleBucket tracks sampling state for one key.
+type sampleBucket struct {
+	seen        int64
+	windowStart time.Time
+	windowCount int
+}
+
+// newLogSampler returns nil when cfg is nil, meaning every call is logged.
+func newLogSampler(cfg *LogSampling) *logSampler {
+	if cfg == nil {
+		return nil
+	}
+	return &logSampler{cfg: *cfg, buckets: make(map[string]*sampleBucket)}
+}
+
+// allow reports whether a call to method should be logged.
+func (s *logSampler) allow(method string, failed bool, now time.Time) bool {
+	if s == nil || (failed && s.cfg.AlwaysLogErrors) {
+		return true
+	}
+	key := ""
+	if s.cfg.PerMethod {
+		key = method
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &sampleBucket{}
+		s.buckets[key] = b
+	}
+	b.seen++
+	if s.cfg.Every > 1 && (b.seen-1)%int64(s.cfg.Every) != 0 {
+		return false
+	}
+	if s.cfg.PerSecond > 0 {
+		if now.Sub(b.windowStart) >= time.Second {
+			b.windowStart, b.windowCount = now, 0
+		}
+		if b.windowCount >= s.cfg.PerSecond {
+			return false
+		}
+		b.windowCount++
+	}
+	return true
+}
+
+// logCall writes one log record for a finished call.
+func (c *rpcClient) logCall(req *RPCRequest, d time.Duration, err error) {
+	if c.logger == nil || !c.logSampler.allow(req.Method, err != nil, time.Now()) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.Int("id", req.ID),
+		slog.Duration("duration", d),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		c.logger.LogAttrs(context.Background(), slog.LevelWarn, "rpc call failed", attrs...)
+		return
+	}
+	c.logger.LogAttrs(context.Background(), slog.LevelInfo, "rpc call", attrs...)
+}
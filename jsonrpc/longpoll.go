@@ -0,0 +1,123 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errCodeNotFound is the code servers answer poll.next with once a
+// subscription has expired.
+const errCodeNotFound = -32004
+
+// PollEvent is an event delivered to a Poller.
+type PollEvent struct {
+	Seq   uint64          `json:"seq"`
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// PollerOpts configures a Poller.
+type PollerOpts struct {
+	// Wait is how long the server may hold each poll open; zero leaves it to
+	// the server. The client's Timeout must exceed it.
+	Wait time.Duration
+	// Max bounds the events returned per poll; zero means no limit.
+	Max int
+	// ResumeFrom resumes after the event with this sequence number.
+	ResumeFrom *uint64
+}
+
+// Poller receives topic events by long-polling a server's poll.subscribe and
+// poll.next methods, for networks where WebSocket cannot be used. When the
+// server expires the subscription, the Poller subscribes again from the
+// last event it saw.
+type Poller struct {
+	client  RPCClient
+	pattern string
+	opts    PollerOpts
+
+	mu           sync.Mutex
+	subscription string
+	lastSeq      uint64
+	seen         bool
+}
+
+// NewPoller creates a Poller for the topics matching pattern. It subscribes
+// on the first call to Next.
+func NewPoller(client RPCClient, pattern string, opts *PollerOpts) *Poller {
+	p := &Poller{client: client, pattern: pattern}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.ResumeFrom != nil {
+		p.lastSeq, p.seen = *p.opts.ResumeFrom, true
+	}
+	return p
+}
+
+// Next waits for the next events, returning an empty slice when the wait
+// ended without any.
+func (p *Poller) Next(ctx context.Context) ([]PollEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		if p.subscription == "" {
+			if err := p.subscribe(ctx); err != nil {
+				return nil, err
+			}
+		}
+		var res struct {
+			Events []PollEvent `json:"events"`
+		}
+		err := p.client.CallFor(ctx, &res, "poll.next", map[string]any{
+			"subscription": p.subscription,
+			"waitMs":       p.opts.Wait.Milliseconds(),
+			"max":          p.opts.Max,
+		})
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) && rpcErr.Code == errCodeNotFound && attempt == 0 {
+			p.subscription = ""
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n := len(res.Events); n > 0 {
+			p.lastSeq, p.seen = res.Events[n-1].Seq, true
+		}
+		return res.Events, nil
+	}
+}
+
+// subscribe creates the server-side subscription, resuming after the last
+// event seen.
+func (p *Poller) subscribe(ctx context.Context) error {
+	args := map[string]any{"pattern": p.pattern}
+	if p.seen {
+		args["resumeFrom"] = p.lastSeq
+	}
+	return p.client.CallFor(ctx, &p.subscription, "poll.subscribe", args)
+}
+
+// LastSeq returns the sequence number of the last event received.
+func (p *Poller) LastSeq() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastSeq
+}
+
+// Close ends the server-side subscription.
+func (p *Poller) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.subscription == "" {
+		return nil
+	}
+	var ok bool
+	err := p.client.CallFor(ctx, &ok, "poll.unsubscribe", map[string]any{"subscription": p.subscription})
+	p.subscription = ""
+	return err
+}
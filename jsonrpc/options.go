@@ -0,0 +1,58 @@
+package jsonrpc
+
+import (
+	"context"
+	"time"
+)
+
+// CallOption configures an individual call. Options travel on the context
+// passed to Call, CallFor, CallBatch and friends; see WithCallOptions.
+type CallOption func(*callOptions)
+
+// callOptions holds the per-call settings gathered from the context.
+type callOptions struct {
+	timeout        time.Duration
+	idempotencyKey string
+	paramsMode     ParamsMode
+	timeEncoding   *TimeEncoding
+	bigNumbers     *BigNumberEncoding
+	binary         *BinaryEncoding
+}
+
+// callOptionsKey is the context key under which callOptions are stored.
+type callOptionsKey struct{}
+
+// WithCallOptions returns a copy of ctx carrying opts, layered on top of any
+// options already present in ctx.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	co := callOptionsFrom(ctx)
+	for _, opt := range opts {
+		opt(&co)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, co)
+}
+
+// callOptionsFrom returns the options stored in ctx, or the zero value.
+func callOptionsFrom(ctx context.Context) callOptions {
+	co, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return co
+}
+
+// WithTimeout bounds a call to d, overriding RPCClientOpts.Timeout.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) { o.timeout = d }
+}
+
+// callContext applies the per-call or client default timeout to ctx and
+// assigns the call its idempotency key.
+func (c *rpcClient) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = c.withIdempotencyKey(ctx)
+	timeout := c.timeout
+	if co := callOptionsFrom(ctx); co.timeout > 0 {
+		timeout = co.timeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
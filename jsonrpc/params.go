@@ -0,0 +1,153 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ParamsMode selects how Call shapes its variadic params.
+type ParamsMode int
+
+const (
+	// ParamsAuto applies the heuristics of Params.
+	ParamsAuto ParamsMode = iota
+	// ParamsPositional always sends params as an array.
+	ParamsPositional
+	// ParamsNamed sends a single struct or map argument as an object.
+	ParamsNamed
+)
+
+// errNamedParams is returned when ParamsNamed is used with anything other
+// than a single struct, map or json.RawMessage.
+var errNamedParams = errors.New("named params require a single struct or map argument")
+
+// WithPositionalParams sends the call's params as an array, overriding
+// RPCClientOpts.ParamsMode.
+func WithPositionalParams() CallOption {
+	return func(o *callOptions) { o.paramsMode = ParamsPositional }
+}
+
+// WithNamedParams sends the call's single struct or map argument as an
+// object, overriding RPCClientOpts.ParamsMode.
+func WithNamedParams() CallOption {
+	return func(o *callOptions) { o.paramsMode = ParamsNamed }
+}
+
+// buildParams shapes params according to the per-call or client ParamsMode.
+func (c *rpcClient) buildParams(ctx context.Context, params []any) (any, error) {
+	mode := c.paramsMode
+	if co := callOptionsFrom(ctx); co.paramsMode != ParamsAuto {
+		mode = co.paramsMode
+	}
+	switch mode {
+	case ParamsPositional:
+		if len(params) == 0 {
+			return nil, nil
+		}
+		return params, nil
+	case ParamsNamed:
+		if len(params) != 1 || params[0] == nil {
+			return nil, errNamedParams
+		}
+		if raw, ok := params[0].(json.RawMessage); ok {
+			return raw, nil
+		}
+		if named, ok := namedParams(params[0]); ok {
+			return named, nil
+		}
+		t := reflect.TypeOf(params[0])
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
+			return nil, errNamedParams
+		}
+		return params[0], nil
+	}
+	return Params(params...), nil
+}
+
+// paramField describes one struct field tagged with `rpc:"..."`.
+type paramField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// paramFieldCache maps a struct type to its []paramField, or nil when the
+// type has no rpc tags.
+var paramFieldCache sync.Map
+
+// paramFields returns the rpc-tagged fields of struct type t, or nil if none
+// of its fields carry an rpc tag.
+func paramFields(t reflect.Type) []paramField {
+	if cached, ok := paramFieldCache.Load(t); ok {
+		return cached.([]paramField)
+	}
+	var (
+		fields []paramField
+		tagged bool
+	)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("rpc")
+		tagged = tagged || ok
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, paramField{index: i, name: name, omitEmpty: opts == "omitempty"})
+	}
+	if !tagged {
+		fields = nil
+	}
+	paramFieldCache.Store(t, fields)
+	return fields
+}
+
+// namedParams builds the named-params object for a struct whose fields carry
+// `rpc:"name,omitempty"` tags. It reports false for structs without rpc tags,
+// which are encoded by the JSON engine as usual.
+func namedParams(v any) (map[string]any, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	fields := paramFields(rv.Type())
+	if fields == nil {
+		return nil, false
+	}
+	out := make(map[string]any, len(fields))
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		out[f.name] = fv.Interface()
+	}
+	return out, true
+}
+
+// isEmptyValue mirrors encoding/json's notion of empty for omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,136 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize keeps unusually large buffers out of the pool.
+const maxPooledBufferSize = 1 << 20
+
+// encodeBuffer pairs a reusable buffer with an encoder writing into it.
+type encodeBuffer struct {
+	buf    bytes.Buffer
+	enc    JSONEncoder
+	engine JSONEngine
+	format encodeFormat
+}
+
+// encodeFormat holds the encoder output settings of a client.
+type encodeFormat struct {
+	noEscapeHTML bool
+	indent       string
+}
+
+var encodeBufferPool = sync.Pool{
+	New: func() any { return &encodeBuffer{} },
+}
+
+// acquireEncodeBuffer returns an empty pooled encode buffer using engine
+// configured with format.
+func acquireEncodeBuffer(engine JSONEngine, format encodeFormat) *encodeBuffer {
+	b := encodeBufferPool.Get().(*encodeBuffer)
+	b.buf.Reset()
+	if b.enc == nil || b.engine != engine || b.format != format {
+		b.engine, b.format = engine, format
+		b.enc = engine.NewEncoder(&b.buf)
+		if e, ok := b.enc.(interface{ SetEscapeHTML(bool) }); ok {
+			e.SetEscapeHTML(!format.noEscapeHTML)
+		}
+		if e, ok := b.enc.(interface{ SetIndent(prefix, indent string) }); ok && format.indent != "" {
+			e.SetIndent("", format.indent)
+		}
+	}
+	return b
+}
+
+// releaseEncodeBuffer returns b to the pool unless it grew too large.
+func releaseEncodeBuffer(b *encodeBuffer) {
+	if b.buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	encodeBufferPool.Put(b)
+}
+
+// encode writes v as JSON and returns the bytes without the trailing newline.
+func (b *encodeBuffer) encode(v any) ([]byte, error) {
+	if err := b.enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.buf.Bytes(), []byte("\n")), nil
+}
+
+// bodyReaderPool holds the buffered readers that response bodies are
+// decoded through.
+var bodyReaderPool = sync.Pool{
+	New: func() any { return bufio.NewReaderSize(nil, maxErrorSnippet) },
+}
+
+// acquireBodyReader returns a pooled buffered reader reading from r.
+func acquireBodyReader(r io.Reader) *bufio.Reader {
+	br := bodyReaderPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	return br
+}
+
+// releaseBodyReader detaches br from its reader and returns it to the pool.
+func releaseBodyReader(br *bufio.Reader) {
+	br.Reset(nil)
+	bodyReaderPool.Put(br)
+}
+
+var requestPool = sync.Pool{New: func() any { return new(RPCRequest) }}
+
+var responsePool = sync.Pool{New: func() any { return new(RPCResponse) }}
+
+var wireResponsePool = sync.Pool{New: func() any { return new(wireResponse) }}
+
+// AcquireRequest returns an empty RPCRequest from the pool.
+// Pass it to ReleaseRequest once it is no longer referenced.
+func AcquireRequest() *RPCRequest {
+	return requestPool.Get().(*RPCRequest)
+}
+
+// ReleaseRequest resets req and returns it to the pool.
+func ReleaseRequest(req *RPCRequest) {
+	if req == nil {
+		return
+	}
+	*req = RPCRequest{}
+	requestPool.Put(req)
+}
+
+// AcquireResponse returns an empty RPCResponse from the pool.
+// Responses returned by the client come from this pool and may be released once consumed.
+func AcquireResponse() *RPCResponse {
+	return responsePool.Get().(*RPCResponse)
+}
+
+// ReleaseResponse resets resp and returns it to the pool.
+func ReleaseResponse(resp *RPCResponse) {
+	if resp == nil {
+		return
+	}
+	*resp = RPCResponse{}
+	responsePool.Put(resp)
+}
+
+// ReleaseResponses releases every response in res.
+func ReleaseResponses(res RPCResponses) {
+	for _, r := range res {
+		ReleaseResponse(r)
+	}
+}
+
+// acquireWireResponse returns an empty wireResponse from the pool.
+func acquireWireResponse() *wireResponse {
+	return wireResponsePool.Get().(*wireResponse)
+}
+
+// releaseWireResponse resets w and returns it to the pool.
+func releaseWireResponse(w *wireResponse) {
+	*w = wireResponse{}
+	wireResponsePool.Put(w)
+}
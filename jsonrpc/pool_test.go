@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// cannedClient answers every request with body, so benchmarks measure the
+// client's encode and decode paths rather than the network.
+type cannedClient struct {
+	body []byte
+}
+
+func (c *cannedClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(c.body)),
+		Request:    req,
+	}, nil
+}
+
+type benchParams struct {
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Count int      `json:"count"`
+}
+
+var benchArgs = benchParams{Name: "widget", Tags: []string{"a", "b", "c"}, Count: 42}
+
+const benchResult = `{"name":"widget","tags":["a","b","c"],"count":42,"ok":true}`
+
+func benchClient(body string) RPCClient {
+	return NewClientWithOpts("http://rpc.invalid/", &RPCClientOpts{HTTPClient: &cannedClient{body: []byte(body)}})
+}
+
+func BenchmarkDoCall(b *testing.B) {
+	c := benchClient(`{"id":1,"result":` + benchResult + `}`)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		resp, err := c.Call(ctx, "bench", &benchArgs)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ReleaseResponse(resp)
+	}
+}
+
+func BenchmarkDoBatchCall(b *testing.B) {
+	const n = 10
+	var body strings.Builder
+	body.WriteByte('[')
+	for i := range n {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.WriteString(`{"id":` + strconv.Itoa(i+1) + `,"result":` + benchResult + `}`)
+	}
+	body.WriteByte(']')
+	c := NewClientWithOpts("http://rpc.invalid/", &RPCClientOpts{
+		HTTPClient:   &cannedClient{body: []byte(body.String())},
+		KeepBatchIDs: true,
+	})
+	ctx := context.Background()
+	reqs := make(RPCRequests, n)
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range reqs {
+			reqs[i] = NewRequestWithID(i+1, "bench", &benchArgs)
+		}
+		resps, err := c.CallBatch(ctx, reqs)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ReleaseResponses(resps)
+	}
+}
+
+// BenchmarkEncode compares the pooled request encoder with json.Marshal.
+func BenchmarkEncode(b *testing.B) {
+	req := &RPCRequest{ID: 1, Method: "bench", Params: &benchArgs}
+	b.Run("Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			body, err := json.Marshal(req)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = bytes.NewReader(body)
+		}
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			eb := acquireEncodeBuffer(StdJSON, encodeFormat{})
+			body, err := eb.encode(req)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = bytes.NewReader(body)
+			releaseEncodeBuffer(eb)
+		}
+	})
+}
+
+// BenchmarkDecode compares decoding a response through the pooled reader
+// and wireResponse with decoding through fresh ones.
+func BenchmarkDecode(b *testing.B) {
+	body := []byte(`{"id":1,"result":` + benchResult + `}`)
+	c := NewClient("http://rpc.invalid/").(*rpcClient)
+	b.Run("Unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var wire *wireResponse
+			dec := json.NewDecoder(bufio.NewReaderSize(bytes.NewReader(body), maxErrorSnippet))
+			if err := dec.Decode(&wire); err != nil {
+				b.Fatal(err)
+			}
+			resp, err := wire.toResponse(StdJSON)
+			if err != nil {
+				b.Fatal(err)
+			}
+			ReleaseResponse(resp)
+		}
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			br := acquireBodyReader(bytes.NewReader(body))
+			resp, err := c.decodeResponse(StdJSON.NewDecoder(br))
+			if err != nil {
+				b.Fatal(err)
+			}
+			releaseBodyReader(br)
+			ReleaseResponse(resp)
+		}
+	})
+}
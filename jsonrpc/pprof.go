@@ -0,0 +1,15 @@
+package jsonrpc
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// withProfilerLabels runs fn under pprof labels naming the method and endpoint.
+func (c *rpcClient) withProfilerLabels(ctx context.Context, method string, fn func(context.Context)) {
+	if !c.profilerLabels {
+		fn(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels("rpc.method", method, "rpc.endpoint", c.endpoint), fn)
+}
@@ -0,0 +1,67 @@
+package jsonrpc
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchProgress describes one decoded batch entry.
+type BatchProgress struct {
+	// Index is the position of the answered request in the batch, or -1 when
+	// the response ID matches no request.
+	Index int
+	ID    int
+	// Err is the entry's RPC error, if any.
+	Err error
+	// Decoded counts the entries decoded so far, out of Total requests.
+	Decoded, Total int
+}
+
+// progressTracker reports batch progress for one batch call.
+type progressTracker struct {
+	fn    func(BatchProgress)
+	pos   map[int]int
+	total int
+
+	mu      sync.Mutex
+	decoded int
+}
+
+// progressKey is the context key under which the progressTracker is stored.
+type progressKey struct{}
+
+// withBatchProgress attaches a tracker for reqs to ctx when a progress
+// callback is configured.
+func (c *rpcClient) withBatchProgress(ctx context.Context, reqs []*RPCRequest) context.Context {
+	if c.onBatchProgress == nil {
+		return ctx
+	}
+	t := &progressTracker{fn: c.onBatchProgress, pos: make(map[int]int, len(reqs)), total: len(reqs)}
+	for i, req := range reqs {
+		if _, dup := t.pos[req.ID]; !dup {
+			t.pos[req.ID] = i
+		}
+	}
+	return context.WithValue(ctx, progressKey{}, t)
+}
+
+// reportProgress hands the decoded resp to the batch progress callback.
+// Calls are serialized even when chunks decode concurrently.
+func reportProgress(ctx context.Context, resp *RPCResponse) {
+	t, _ := ctx.Value(progressKey{}).(*progressTracker)
+	if t == nil || resp == nil {
+		return
+	}
+	p := BatchProgress{Index: -1, ID: resp.ID, Total: t.total}
+	if i, ok := t.pos[resp.ID]; ok {
+		p.Index = i
+	}
+	if resp.Error != nil {
+		p.Err = resp.Error
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decoded++
+	p.Decoded = t.decoded
+	t.fn(p)
+}
@@ -0,0 +1,173 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a call exceeds the client-side rate limit.
+var ErrRateLimited = errors.New("client rate limit exceeded")
+
+// RateLimit configures a token bucket.
+type RateLimit struct {
+	// PerSecond is the sustained number of requests allowed per second.
+	PerSecond float64
+	// Burst is the bucket capacity; values below one are treated as one.
+	Burst int
+}
+
+// tokenBucket is a refilling token bucket that supports reservations.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket for l.
+func newTokenBucket(l RateLimit) *tokenBucket {
+	burst := float64(max(l.Burst, 1))
+	return &tokenBucket{rate: l.PerSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// refill adds the tokens accrued since the last update. b.mu must be held.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// take removes n tokens if they are available right now.
+func (b *tokenBucket) take(n float64, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	n = min(n, b.burst)
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// reserve removes n tokens, possibly going into debt, and returns how long
+// the caller must wait before the reservation is honored.
+func (b *tokenBucket) reserve(n float64, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.tokens -= min(n, b.burst)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// refund returns n previously taken or reserved tokens.
+func (b *tokenBucket) refund(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+min(n, b.burst))
+}
+
+// rateLimiter applies a global bucket and optional per-method buckets.
+type rateLimiter struct {
+	global  *tokenBucket
+	methods map[string]*tokenBucket
+	reject  bool
+}
+
+// newRateLimiter returns nil when no limits are configured.
+func newRateLimiter(global *RateLimit, methods map[string]RateLimit, reject bool) *rateLimiter {
+	if (global == nil || global.PerSecond <= 0) && len(methods) == 0 {
+		return nil
+	}
+	l := &rateLimiter{methods: make(map[string]*tokenBucket, len(methods)), reject: reject}
+	if global != nil && global.PerSecond > 0 {
+		l.global = newTokenBucket(*global)
+	}
+	for name, m := range methods {
+		if m.PerSecond > 0 {
+			l.methods[name] = newTokenBucket(m)
+		}
+	}
+	return l
+}
+
+// bucketCost pairs a bucket with the tokens a call takes from it.
+type bucketCost struct {
+	bucket *tokenBucket
+	n      float64
+}
+
+// costs returns the buckets charged by reqs.
+func (l *rateLimiter) costs(reqs []*RPCRequest) []bucketCost {
+	var out []bucketCost
+	if l.global != nil {
+		out = append(out, bucketCost{l.global, float64(len(reqs))})
+	}
+	perMethod := make(map[*tokenBucket]float64)
+	for _, req := range reqs {
+		if b, ok := l.methods[req.Method]; ok {
+			perMethod[b]++
+		}
+	}
+	for b, n := range perMethod {
+		out = append(out, bucketCost{b, n})
+	}
+	return out
+}
+
+// wait blocks until reqs fit within the limits, or rejects them.
+// Waits that would outlive the ctx deadline fail immediately.
+func (l *rateLimiter) wait(ctx context.Context, reqs []*RPCRequest) error {
+	costs := l.costs(reqs)
+	now := time.Now()
+	if l.reject {
+		for i, c := range costs {
+			if !c.bucket.take(c.n, now) {
+				refundAll(costs[:i])
+				return ErrRateLimited
+			}
+		}
+		return nil
+	}
+	var delay time.Duration
+	for _, c := range costs {
+		delay = max(delay, c.bucket.reserve(c.n, now))
+	}
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		refundAll(costs)
+		return fmt.Errorf("%w: waiting %v would exceed the context deadline", ErrRateLimited, delay)
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		refundAll(costs)
+		return ctx.Err()
+	}
+}
+
+// refundAll returns the tokens charged for costs.
+func refundAll(costs []bucketCost) {
+	for _, c := range costs {
+		c.bucket.refund(c.n)
+	}
+}
+
+// waitRate applies the client rate limit to reqs, if one is configured.
+func (c *rpcClient) waitRate(ctx context.Context, reqs ...*RPCRequest) error {
+	if c.rateLimiter == nil {
+		return nil
+	}
+	return c.rateLimiter.wait(ctx, reqs)
+}
@@ -0,0 +1,99 @@
+package jsonrpc
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+)
+
+// ReAuthFunc refreshes credentials after the server rejected a request with
+// 401 or 403. The returned headers are merged into the client's custom headers
+// and applied to the retried request and every later one.
+type ReAuthFunc func(ctx context.Context, statusCode int) (map[string]string, error)
+
+// reauthenticator serializes credential refreshes so that concurrent
+// rejections trigger a single ReAuthFunc call.
+type reauthenticator struct {
+	fn  ReAuthFunc
+	mu  sync.Mutex
+	gen uint64
+}
+
+// newReauthenticator returns nil when fn is nil.
+func newReauthenticator(fn ReAuthFunc) *reauthenticator {
+	if fn == nil {
+		return nil
+	}
+	return &reauthenticator{fn: fn}
+}
+
+// generation reports how many refreshes have completed.
+func (r *reauthenticator) generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gen
+}
+
+// isAuthFailure reports whether status asks the client to re-authenticate.
+func isAuthFailure(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// sendWithReauth sends httpReq and, on 401/403, refreshes credentials and
+// retries it once with the updated headers.
+func (c *rpcClient) sendWithReauth(httpReq *http.Request, method string) (*http.Response, error) {
+	if c.reauth == nil {
+		return c.sendFollowingRedirects(httpReq, method)
+	}
+	gen := c.reauth.generation()
+	httpResp, err := c.sendFollowingRedirects(httpReq, method)
+	if err != nil || !isAuthFailure(httpResp.StatusCode) {
+		return httpResp, err
+	}
+	body, err := replayBody(httpReq)
+	if err != nil {
+		return httpResp, nil
+	}
+	httpResp.Body.Close()
+	if err := c.refreshCredentials(httpReq.Context(), gen, httpResp.StatusCode); err != nil {
+		return nil, err
+	}
+	retry := httpReq.Clone(httpReq.Context())
+	retry.Body = body
+	c.applyHeaders(retry)
+	return c.sendFollowingRedirects(retry, method)
+}
+
+// refreshCredentials runs the ReAuthFunc unless another call already
+// refreshed since generation gen was observed.
+func (c *rpcClient) refreshCredentials(ctx context.Context, gen uint64, status int) error {
+	r := c.reauth
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gen != gen {
+		return nil
+	}
+	headers, err := r.fn(ctx, status)
+	if err != nil {
+		return err
+	}
+	c.headersMu.Lock()
+	maps.Copy(c.customHeaders, headers)
+	c.headersMu.Unlock()
+	r.gen++
+	return nil
+}
+
+// applyHeaders sets the client's custom headers on httpReq.
+func (c *rpcClient) applyHeaders(httpReq *http.Request) {
+	c.headersMu.RLock()
+	defer c.headersMu.RUnlock()
+	for k, v := range c.customHeaders {
+		if k == "Host" {
+			httpReq.Host = v
+		} else {
+			httpReq.Header.Set(k, v)
+		}
+	}
+}
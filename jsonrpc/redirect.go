@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxRedirects bounds the redirects followed for a single exchange.
+const maxRedirects = 10
+
+// RedirectPolicy selects how redirects of RPC POSTs are handled.
+type RedirectPolicy int
+
+const (
+	// RedirectDefault leaves redirects to the HTTP client, which replays the
+	// body on 307/308 but turns 301/302/303 into a bodiless GET.
+	RedirectDefault RedirectPolicy = iota
+	// RedirectFollow re-sends the POST with its body on any redirect to the
+	// same host. Redirects to another host fail unless
+	// RPCClientOpts.RedirectCrossHost is set, and are then sent without the
+	// credentials of the client.
+	RedirectFollow
+	// RedirectFail returns a *RedirectError instead of following.
+	RedirectFail
+	// RedirectRewrite follows like RedirectFollow and, on 301/308 to the same
+	// host, sends all later calls to the new location.
+	RedirectRewrite
+)
+
+// RedirectError is returned when the server redirects an RPC POST and the
+// RedirectPolicy does not allow following it.
+type RedirectError struct {
+	StatusCode int
+	Location   string
+	err        error
+}
+
+// Error implements the error interface for RedirectError.
+func (e *RedirectError) Error() string { return e.err.Error() }
+
+// newRedirectError builds a RedirectError with a descriptive message.
+func newRedirectError(status int, location, reason string) *RedirectError {
+	return &RedirectError{
+		StatusCode: status,
+		Location:   location,
+		err:        fmt.Errorf("rpc endpoint redirected with status %d to %q: %s", status, location, reason),
+	}
+}
+
+// isRedirect reports whether status is a redirect carrying a Location.
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// noFollowClient returns a copy of hc that hands redirects back to the caller.
+// Clients other than *http.Client are returned unchanged and must not follow
+// redirects themselves for the policy to take effect.
+func noFollowClient(hc HTTPClient) HTTPClient {
+	std, ok := hc.(*http.Client)
+	if !ok {
+		return hc
+	}
+	cp := *std
+	cp.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &cp
+}
+
+// currentEndpoint returns the endpoint calls are sent to, which differs from
+// the configured one after a RedirectRewrite or when a Resolver is set.
+func (c *rpcClient) currentEndpoint() string {
+	if p := c.rewrittenEndpoint.Load(); p != nil {
+		return *p
+	}
+	if c.endpoints != nil {
+		if e := c.endpoints.pick(); e != "" {
+			return e
+		}
+	}
+	return c.endpoint
+}
+
+// sendFollowingRedirects sends httpReq and applies the redirect policy to the
+// response.
+func (c *rpcClient) sendFollowingRedirects(httpReq *http.Request, method string) (*http.Response, error) {
+	if c.redirectPolicy == RedirectDefault {
+		return c.sendHTTP(httpReq, method)
+	}
+	for range maxRedirects {
+		httpResp, err := c.sendHTTP(httpReq, method)
+		if err != nil || !isRedirect(httpResp.StatusCode) {
+			return httpResp, err
+		}
+		httpResp.Body.Close()
+		status, location := httpResp.StatusCode, httpResp.Header.Get("Location")
+		if c.redirectPolicy == RedirectFail {
+			return nil, newRedirectError(status, location, "redirects are disabled by RedirectFail")
+		}
+		if location == "" {
+			return nil, newRedirectError(status, location, "missing Location header")
+		}
+		target, err := httpReq.URL.Parse(location)
+		if err != nil {
+			return nil, newRedirectError(status, location, err.Error())
+		}
+		crossHost := !strings.EqualFold(target.Host, httpReq.URL.Host)
+		if crossHost && !c.redirectCrossHost {
+			return nil, newRedirectError(status, location, "redirect to another host; set RedirectCrossHost to follow it")
+		}
+		body, err := replayBody(httpReq)
+		if err != nil {
+			return nil, newRedirectError(status, location, err.Error())
+		}
+		next := httpReq.Clone(httpReq.Context())
+		next.URL, next.Host, next.Body = target, "", body
+		if crossHost {
+			c.stripCredentials(next)
+		} else if c.redirectPolicy == RedirectRewrite && (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect) {
+			endpoint := target.String()
+			c.rewrittenEndpoint.Store(&endpoint)
+		}
+		httpReq = next
+	}
+	return nil, fmt.Errorf("rpc endpoint stopped after %d redirects", maxRedirects)
+}
+
+// stripCredentials removes the headers of httpReq that may authenticate
+// the client, including every configured custom header and those added by
+// interceptors, before it leaves for another host.
+func (c *rpcClient) stripCredentials(httpReq *http.Request) {
+	h := httpReq.Header
+	if info := CallInfoFromContext(httpReq.Context()); info != nil && info.Side == ClientSide {
+		for k := range info.Header {
+			h.Del(k)
+		}
+	}
+	h.Del("Authorization")
+	h.Del("Proxy-Authorization")
+	h.Del("Cookie")
+	c.headersMu.RLock()
+	defer c.headersMu.RUnlock()
+	for k := range c.customHeaders {
+		h.Del(k)
+	}
+}
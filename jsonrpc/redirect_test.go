@@ -0,0 +1,45 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectCrossHostDropsCredentials(t *testing.T) {
+	var got http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":1,"result":"ok"}`)
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusTemporaryRedirect)
+	}))
+	defer origin.Close()
+	headers := map[string]string{"Authorization": "Bearer secret", "X-Api-Key": "key"}
+
+	c := NewClientWithOpts(origin.URL, &RPCClientOpts{RedirectPolicy: RedirectFollow, CustomHeaders: headers})
+	_, err := c.Call(context.Background(), "ping")
+	var rerr *RedirectError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("cross-host redirect: err = %v, want *RedirectError", err)
+	}
+	if got != nil {
+		t.Fatal("cross-host redirect was followed without RedirectCrossHost")
+	}
+
+	c = NewClientWithOpts(origin.URL, &RPCClientOpts{RedirectPolicy: RedirectFollow, RedirectCrossHost: true, CustomHeaders: headers})
+	if _, err := c.Call(context.Background(), "ping"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Authorization", "X-Api-Key"} {
+		if v := got.Get(name); v != "" {
+			t.Errorf("%s = %q sent to another host", name, v)
+		}
+	}
+}
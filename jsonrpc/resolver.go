@@ -0,0 +1,137 @@
+package jsonrpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultResolveInterval is used when RPCClientOpts.ResolveInterval is zero.
+const defaultResolveInterval = 30 * time.Second
+
+// resolveTimeout bounds a single Resolve call.
+const resolveTimeout = 5 * time.Second
+
+// Resolver maps a logical service to the endpoints currently serving it.
+// Implementations backed by Consul, etcd or similar plug in here.
+type Resolver interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(ctx context.Context) ([]string, error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(ctx context.Context) ([]string, error) { return f(ctx) }
+
+// SRVResolver resolves endpoints from DNS SRV records, ordered by priority
+// and weight as returned by net.Resolver.LookupSRV.
+type SRVResolver struct {
+	// Service, Proto and Name form the query _Service._Proto.Name;
+	// empty Service and Proto look up Name directly.
+	Service, Proto, Name string
+	// Scheme defaults to "http".
+	Scheme string
+	// Path is appended to every endpoint, e.g. "/rpc".
+	Path string
+	// Resolver defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// Resolve looks up the SRV records and builds one endpoint per target.
+func (r *SRVResolver) Resolve(ctx context.Context) ([]string, error) {
+	res := r.Resolver
+	if res == nil {
+		res = net.DefaultResolver
+	}
+	_, srvs, err := res.LookupSRV(ctx, r.Service, r.Proto, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	scheme := r.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	endpoints := make([]string, 0, len(srvs))
+	for _, srv := range srvs {
+		host := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+		endpoints = append(endpoints, scheme+"://"+host+r.Path)
+	}
+	return endpoints, nil
+}
+
+// endpointSet holds the resolved endpoints and rotates through them.
+// It refreshes lazily: the first pick resolves synchronously and later picks
+// past the refresh interval trigger a background refresh.
+type endpointSet struct {
+	resolver Resolver
+	interval time.Duration
+
+	endpoints  atomic.Pointer[[]string]
+	next       atomic.Uint64
+	refreshing atomic.Bool
+
+	mu       sync.Mutex
+	resolved time.Time
+}
+
+// newEndpointSet returns nil when r is nil.
+func newEndpointSet(r Resolver, interval time.Duration) *endpointSet {
+	if r == nil {
+		return nil
+	}
+	if interval <= 0 {
+		interval = defaultResolveInterval
+	}
+	return &endpointSet{resolver: r, interval: interval}
+}
+
+// pick returns the next endpoint, or "" when none has been resolved.
+func (s *endpointSet) pick() string {
+	p := s.endpoints.Load()
+	if p == nil {
+		s.refresh()
+		p = s.endpoints.Load()
+	} else if s.stale() && s.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer s.refreshing.Store(false)
+			s.refresh()
+		}()
+	}
+	if p == nil || len(*p) == 0 {
+		return ""
+	}
+	list := *p
+	return list[(s.next.Add(1)-1)%uint64(len(list))]
+}
+
+// stale reports whether the refresh interval has passed.
+func (s *endpointSet) stale() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Since(s.resolved) >= s.interval
+}
+
+// refresh resolves the endpoints, keeping the previous list on failure.
+func (s *endpointSet) refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.endpoints.Load() != nil && time.Since(s.resolved) < s.interval {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	endpoints, err := s.resolver.Resolve(ctx)
+	s.resolved = time.Now()
+	if err != nil || len(endpoints) == 0 {
+		if s.endpoints.Load() == nil {
+			s.endpoints.Store(&[]string{})
+		}
+		return
+	}
+	s.endpoints.Store(&endpoints)
+}
@@ -0,0 +1,379 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Schema is the subset of JSON Schema the client validates against: type,
+// enum, numeric and length bounds, pattern, object properties and array items.
+// It unmarshals from the usual JSON Schema document form.
+type Schema struct {
+	Type                 SchemaType         `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	patternOnce sync.Once
+	pattern     *regexp.Regexp
+	patternErr  error
+}
+
+// SchemaType lists the JSON types a value may have. It unmarshals from either
+// a single type name or an array of names.
+type SchemaType []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = SchemaType{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// OpenRPCMethod is the part of an OpenRPC method descriptor used for validation.
+type OpenRPCMethod struct {
+	Name string `json:"name"`
+	// ParamStructure is "by-name", "by-position" or "either" (the default).
+	ParamStructure string              `json:"paramStructure,omitempty"`
+	Params         []OpenRPCDescriptor `json:"params"`
+	Result         *OpenRPCDescriptor  `json:"result,omitempty"`
+}
+
+// OpenRPCDescriptor describes one param or the result of an OpenRPC method.
+type OpenRPCDescriptor struct {
+	Name     string  `json:"name"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// SchemaViolation is a single validation failure at a JSON path such as
+// "params.user.age" or "params[1]".
+type SchemaViolation struct {
+	Path    string
+	Message string
+}
+
+// ValidationError is returned when a call's params or result fail schema
+// validation. Requests with invalid params are not sent.
+type ValidationError struct {
+	Method string
+	// Subject is "params" or "result".
+	Subject    string
+	Violations []SchemaViolation
+}
+
+// Error implements the error interface for ValidationError.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Path + ": " + v.Message
+	}
+	return fmt.Sprintf("rpc call %v() invalid %s: %s", e.Method, e.Subject, strings.Join(msgs, "; "))
+}
+
+// schemaValidator collects violations while walking a value.
+type schemaValidator struct {
+	violations []SchemaViolation
+}
+
+// fail records a violation at path.
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks the generic JSON value g against s.
+func (v *schemaValidator) validate(s *Schema, g any, path string) {
+	if s == nil {
+		return
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasJSONType(g, t) }) {
+		v.fail(path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonTypeOf(g))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, g) }) {
+		v.fail(path, "value %v is not one of %v", g, s.Enum)
+	}
+	switch val := g.(type) {
+	case json.Number:
+		f, _ := val.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			v.fail(path, "%v is less than minimum %v", val, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			v.fail(path, "%v is greater than maximum %v", val, *s.Maximum)
+		}
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			v.fail(path, "length %d is less than minLength %d", n, *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			v.fail(path, "length %d is greater than maxLength %d", n, *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			re, err := s.compiledPattern()
+			if err != nil {
+				v.fail(path, "invalid schema pattern %q: %v", s.Pattern, err)
+			} else if !re.MatchString(val) {
+				v.fail(path, "%q does not match pattern %q", val, s.Pattern)
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			v.fail(path, "%d items is less than minItems %d", len(val), *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			v.fail(path, "%d items is more than maxItems %d", len(val), *s.MaxItems)
+		}
+		for i, item := range val {
+			v.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				v.fail(path+"."+name, "is required")
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(val)) {
+			item := val[name]
+			if ps, ok := s.Properties[name]; ok {
+				v.validate(ps, item, path+"."+name)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				v.fail(path+"."+name, "is not allowed")
+			}
+		}
+	}
+}
+
+// validateMethod checks params against an OpenRPC method descriptor.
+func (v *schemaValidator) validateMethod(m *OpenRPCMethod, params any) {
+	switch p := params.(type) {
+	case []any:
+		if m.ParamStructure == "by-name" {
+			v.fail("params", "expected named params")
+			return
+		}
+		if len(p) > len(m.Params) {
+			v.fail("params", "got %d params, want at most %d", len(p), len(m.Params))
+		}
+		for i, d := range m.Params {
+			path := "params[" + strconv.Itoa(i) + "]"
+			if i >= len(p) {
+				if d.Required {
+					v.fail(path, "required param %q is missing", d.Name)
+				}
+				continue
+			}
+			v.validate(d.Schema, p[i], path)
+		}
+	case map[string]any:
+		if m.ParamStructure == "by-position" {
+			v.fail("params", "expected positional params")
+			return
+		}
+		for _, d := range m.Params {
+			val, ok := p[d.Name]
+			if !ok {
+				if d.Required {
+					v.fail("params."+d.Name, "is required")
+				}
+				continue
+			}
+			v.validate(d.Schema, val, "params."+d.Name)
+		}
+	case nil:
+		for _, d := range m.Params {
+			if d.Required {
+				v.fail("params", "required param %q is missing", d.Name)
+			}
+		}
+	default:
+		v.fail("params", "expected an array or object, got %s", jsonTypeOf(params))
+	}
+}
+
+// compiledPattern compiles Pattern once.
+func (s *Schema) compiledPattern() (*regexp.Regexp, error) {
+	s.patternOnce.Do(func() { s.pattern, s.patternErr = regexp.Compile(s.Pattern) })
+	return s.pattern, s.patternErr
+}
+
+// hasJSONType reports whether g is of JSON Schema type t.
+func hasJSONType(g any, t string) bool {
+	if t == "integer" {
+		n, ok := g.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	}
+	return jsonTypeOf(g) == t
+}
+
+// jsonTypeOf names the JSON Schema type of a generic JSON value.
+func jsonTypeOf(g any) string {
+	switch g.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", g)
+}
+
+// jsonEqual compares an enum member with a generic JSON value.
+func jsonEqual(a, b any) bool {
+	if n, ok := b.(json.Number); ok {
+		f, _ := n.Float64()
+		switch av := a.(type) {
+		case json.Number:
+			af, _ := av.Float64()
+			return af == f
+		case float64:
+			return av == f
+		case int:
+			return float64(av) == f
+		}
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// ResultValidationMode selects what happens when a result fails validation.
+type ResultValidationMode int
+
+const (
+	// ResultValidationError returns a *ValidationError alongside the response.
+	ResultValidationError ResultValidationMode = iota
+	// ResultValidationWarn reports violations to RPCClientOpts.OnResultViolation,
+	// or logs them to RPCClientOpts.Logger, and returns the response normally.
+	ResultValidationWarn
+)
+
+// methodSchemas holds the per-method schemas params and results are
+// validated against.
+type methodSchemas struct {
+	params  map[string]*Schema
+	results map[string]*Schema
+	methods map[string]*OpenRPCMethod
+}
+
+// newMethodSchemas returns nil when nothing is registered.
+func newMethodSchemas(params, results map[string]*Schema, methods []OpenRPCMethod) *methodSchemas {
+	if len(params) == 0 && len(results) == 0 && len(methods) == 0 {
+		return nil
+	}
+	m := &methodSchemas{params: params, results: results, methods: make(map[string]*OpenRPCMethod, len(methods))}
+	for i := range methods {
+		m.methods[methods[i].Name] = &methods[i]
+	}
+	return m
+}
+
+// validateParams checks req's params against its registered schema, if any.
+func (c *rpcClient) validateParams(req *RPCRequest) error {
+	if c.schemas == nil {
+		return nil
+	}
+	schema, hasSchema := c.schemas.params[req.Method]
+	method, hasMethod := c.schemas.methods[req.Method]
+	if !hasSchema && !hasMethod {
+		return nil
+	}
+	var g any
+	if req.Params != nil {
+		js, err := c.json.Marshal(req.Params)
+		if err != nil {
+			return err
+		}
+		if g, err = decodeGeneric(c.json, js); err != nil {
+			return err
+		}
+	}
+	var v schemaValidator
+	if hasSchema {
+		v.validate(schema, g, "params")
+	}
+	if hasMethod {
+		v.validateMethod(method, g)
+	}
+	if len(v.violations) > 0 {
+		return &ValidationError{Method: req.Method, Subject: "params", Violations: v.violations}
+	}
+	return nil
+}
+
+// validateResult checks a successful response to method against its
+// registered result schema, if any. In warn mode violations are reported
+// and nil is returned.
+func (c *rpcClient) validateResult(method string, resp *RPCResponse) error {
+	if c.schemas == nil || resp == nil || resp.Error != nil {
+		return nil
+	}
+	schema := c.schemas.results[method]
+	if m, ok := c.schemas.methods[method]; ok && schema == nil && m.Result != nil {
+		schema = m.Result.Schema
+	}
+	if schema == nil {
+		return nil
+	}
+	raw, err := resp.RawResult()
+	if err != nil {
+		return err
+	}
+	var g any
+	if len(raw) > 0 {
+		if g, err = decodeGeneric(engineOrDefault(resp.engine), raw); err != nil {
+			return err
+		}
+	}
+	var v schemaValidator
+	v.validate(schema, g, "result")
+	if len(v.violations) == 0 {
+		return nil
+	}
+	verr := &ValidationError{Method: method, Subject: "result", Violations: v.violations}
+	if c.resultValidation != ResultValidationWarn {
+		return verr
+	}
+	if c.onResultViolation != nil {
+		c.onResultViolation(verr)
+	} else if c.logger != nil {
+		c.logger.Warn("rpc result failed schema validation", "method", method, "error", verr.Error())
+	}
+	return nil
+}
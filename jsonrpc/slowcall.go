@@ -0,0 +1,45 @@
+package jsonrpc
+
+import (
+	"maps"
+	"time"
+)
+
+// SlowCall describes a call that exceeded its latency threshold.
+type SlowCall struct {
+	Request   RPCRequest
+	Duration  time.Duration
+	Threshold time.Duration
+	Err       error
+}
+
+// slowCallDetector invokes a callback for calls slower than their threshold.
+type slowCallDetector struct {
+	threshold  time.Duration
+	thresholds map[string]time.Duration
+	callback   func(SlowCall)
+}
+
+// newSlowCallDetector returns nil when no callback or thresholds are configured.
+func newSlowCallDetector(opts *RPCClientOpts) *slowCallDetector {
+	if opts.OnSlowCall == nil || (opts.SlowCallThreshold <= 0 && len(opts.SlowCallThresholds) == 0) {
+		return nil
+	}
+	return &slowCallDetector{
+		threshold:  opts.SlowCallThreshold,
+		thresholds: maps.Clone(opts.SlowCallThresholds),
+		callback:   opts.OnSlowCall,
+	}
+}
+
+// check fires the callback if req took longer than its threshold.
+func (s *slowCallDetector) check(req *RPCRequest, d time.Duration, err error) {
+	limit, ok := s.thresholds[req.Method]
+	if !ok {
+		limit = s.threshold
+	}
+	if limit <= 0 || d <= limit {
+		return
+	}
+	s.callback(SlowCall{Request: *req, Duration: d, Threshold: limit, Err: err})
+}
@@ -0,0 +1,131 @@
+package jsonrpc
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultStatsWindow is the number of latency samples kept per method.
+const defaultStatsWindow = 1024
+
+// MethodStats summarizes calls made to a single RPC method.
+type MethodStats struct {
+	Count     int64
+	Errors    int64
+	ErrorRate float64
+	P50       time.Duration
+	P95       time.Duration
+	P99       time.Duration
+}
+
+// statsRecorder keeps per-method counters and a ring of recent latencies.
+type statsRecorder struct {
+	mu      sync.Mutex
+	window  int
+	methods map[string]*methodRecord
+}
+
+// methodRecord holds the raw samples behind a MethodStats.
+type methodRecord struct {
+	count   int64
+	errors  int64
+	samples []time.Duration
+	next    int
+}
+
+// newStatsRecorder creates a recorder keeping window samples per method.
+func newStatsRecorder(window int) *statsRecorder {
+	if window <= 0 {
+		window = defaultStatsWindow
+	}
+	return &statsRecorder{window: window, methods: make(map[string]*methodRecord)}
+}
+
+// record adds one call outcome for method.
+func (s *statsRecorder) record(method string, d time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.methods[method]
+	if !ok {
+		m = &methodRecord{}
+		s.methods[method] = m
+	}
+	m.count++
+	if failed {
+		m.errors++
+	}
+	if len(m.samples) < s.window {
+		m.samples = append(m.samples, d)
+		return
+	}
+	m.samples[m.next] = d
+	m.next = (m.next + 1) % s.window
+}
+
+// snapshot computes MethodStats for every recorded method.
+func (s *statsRecorder) snapshot() map[string]MethodStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]MethodStats, len(s.methods))
+	for name, m := range s.methods {
+		sorted := slices.Clone(m.samples)
+		slices.Sort(sorted)
+		out[name] = MethodStats{
+			Count:     m.count,
+			Errors:    m.errors,
+			ErrorRate: float64(m.errors) / float64(m.count),
+			P50:       percentile(sorted, 0.50),
+			P95:       percentile(sorted, 0.95),
+			P99:       percentile(sorted, 0.99),
+		}
+	}
+	return out
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+// Stats returns per-method statistics, or nil when stats are disabled.
+func (c *rpcClient) Stats() map[string]MethodStats {
+	if c.stats == nil {
+		return nil
+	}
+	return c.stats.snapshot()
+}
+
+// observe records the outcome of a finished call.
+func (c *rpcClient) observe(req *RPCRequest, resp *RPCResponse, err error, d time.Duration) {
+	if err == nil && resp != nil && resp.Error != nil {
+		err = resp.Error
+	}
+	if c.stats != nil {
+		c.stats.record(req.Method, d, err != nil)
+	}
+	if c.expvars != nil {
+		c.expvars.record(req.Method, d, err != nil)
+	}
+	if c.slowCalls != nil {
+		c.slowCalls.check(req, d, err)
+	}
+	c.logCall(req, d, err)
+}
+
+// observeBatch records the outcome of every entry in a finished batch. An
+// entry the server did not answer counts as failed with ErrNoResponse.
+func (c *rpcClient) observeBatch(reqs []*RPCRequest, resps RPCResponses, err error, d time.Duration) {
+	byID := resps.AsMap()
+	for _, req := range reqs {
+		resp, entryErr := byID[req.ID], err
+		if resp == nil && entryErr == nil {
+			entryErr = ErrNoResponse
+		}
+		c.observe(req, resp, entryErr, d)
+	}
+}
@@ -0,0 +1,68 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// TimeEncoding selects how time.Time values travel on the wire.
+type TimeEncoding int
+
+const (
+	// TimeRFC3339 leaves time.Time to its JSON encoding, an RFC 3339 string.
+	TimeRFC3339 TimeEncoding = iota
+	// TimeUnixSeconds encodes times as integer seconds since the Unix epoch.
+	TimeUnixSeconds
+	// TimeUnixMillis encodes times as integer milliseconds since the Unix epoch.
+	TimeUnixMillis
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// WithTimeEncoding sets the call's time encoding, overriding
+// RPCClientOpts.TimeEncoding.
+func WithTimeEncoding(e TimeEncoding) CallOption {
+	return func(o *callOptions) { o.timeEncoding = &e }
+}
+
+// timeEncodingFor returns the per-call or client time encoding.
+func (c *rpcClient) timeEncodingFor(ctx context.Context) TimeEncoding {
+	if e := callOptionsFrom(ctx).timeEncoding; e != nil {
+		return *e
+	}
+	return c.timeEncoding
+}
+
+// encodeTime re-encodes a time.Time param per enc.
+func encodeTime(rv reflect.Value, enc TimeEncoding) (any, bool) {
+	if enc == TimeRFC3339 || rv.Type() != timeType {
+		return nil, false
+	}
+	t := rv.Interface().(time.Time)
+	if enc == TimeUnixMillis {
+		return t.UnixMilli(), true
+	}
+	return t.Unix(), true
+}
+
+// decodeTime turns a numeric time into an RFC 3339 string that time.Time
+// can unmarshal.
+func decodeTime(t reflect.Type, g any, enc TimeEncoding) (any, bool) {
+	if enc == TimeRFC3339 || t != timeType {
+		return nil, false
+	}
+	n, ok := g.(json.Number)
+	if !ok {
+		return g, true
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return g, true
+	}
+	if enc == TimeUnixMillis {
+		return time.UnixMilli(v).UTC().Format(time.RFC3339Nano), true
+	}
+	return time.Unix(v, 0).UTC().Format(time.RFC3339Nano), true
+}
@@ -0,0 +1,94 @@
+package jsonrpc
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportOpts tunes connection reuse of the client's default HTTP transport.
+// Zero fields keep the http.DefaultTransport settings. It is ignored when
+// RPCClientOpts.HTTPClient is set.
+type TransportOpts struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	TLSHandshakeTimeout time.Duration
+	DisableKeepAlives   bool
+}
+
+// newTransport clones http.DefaultTransport and applies o.
+func newTransport(o *TransportOpts) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if o.MaxIdleConns > 0 {
+		t.MaxIdleConns = o.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	if o.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = o.IdleConnTimeout
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+	t.DisableKeepAlives = o.DisableKeepAlives
+	return t
+}
+
+// doHTTP sends httpReq within the in-flight limit and undoes any content encoding.
+// The limiter slot is held until the response body is closed.
+func (c *rpcClient) doHTTP(httpReq *http.Request, method string) (*http.Response, error) {
+	if c.limiter != nil {
+		if err := c.limiter.acquire(httpReq.Context()); err != nil {
+			return nil, err
+		}
+	}
+	httpResp, err := c.sendWithReauth(httpReq, method)
+	if err != nil {
+		if c.limiter != nil {
+			c.limiter.release()
+		}
+		return nil, err
+	}
+	if c.limiter != nil {
+		httpResp.Body = &releasingBody{ReadCloser: httpResp.Body, release: c.limiter.release}
+	}
+	if err := decompressResponse(httpResp); err != nil {
+		httpResp.Body.Close()
+		return nil, err
+	}
+	return httpResp, nil
+}
+
+// releasingBody runs release once when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close closes the body and runs release.
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// errBodyNotReplayable is returned when a request must be re-sent but its
+// body cannot be read again.
+var errBodyNotReplayable = errors.New("request body cannot be replayed")
+
+// replayBody returns a fresh copy of httpReq's body for re-sending it.
+// Bodiless requests such as GET-encoded calls yield a nil body.
+func replayBody(httpReq *http.Request) (io.ReadCloser, error) {
+	if httpReq.Body == nil || httpReq.Body == http.NoBody {
+		return nil, nil
+	}
+	if httpReq.GetBody == nil {
+		return nil, errBodyNotReplayable
+	}
+	return httpReq.GetBody()
+}
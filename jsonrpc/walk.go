@@ -0,0 +1,154 @@
+package jsonrpc
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// jsonField is a struct field as seen by encoding/json.
+type jsonField struct {
+	name  string
+	index []int
+}
+
+// jsonFieldCache maps a struct type to its []jsonField.
+var jsonFieldCache sync.Map
+
+// jsonFields returns the fields encoding/json would emit for struct type t,
+// flattening untagged embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	if cached, ok := jsonFieldCache.Load(t); ok {
+		return cached.([]jsonField)
+	}
+	var fields []jsonField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for _, sub := range jsonFields(ft) {
+				fields = append(fields, jsonField{name: sub.name, index: append([]int{i}, sub.index...)})
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{name: name, index: []int{i}})
+	}
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
+
+// rewriteEncoded walks g, the generic JSON form of rv, and replaces the
+// values for which leaf reports true. It is used to re-encode values such as
+// time.Time after the JSON engine has marshaled them.
+func rewriteEncoded(rv reflect.Value, g any, leaf func(reflect.Value) (any, bool)) any {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return g
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return g
+	}
+	if out, ok := leaf(rv); ok {
+		return out
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		m, ok := g.(map[string]any)
+		if !ok {
+			return g
+		}
+		for _, f := range jsonFields(rv.Type()) {
+			val, ok := m[f.name]
+			if !ok {
+				continue
+			}
+			fv, err := rv.FieldByIndexErr(f.index)
+			if err != nil {
+				continue
+			}
+			m[f.name] = rewriteEncoded(fv, val, leaf)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := g.([]any)
+		if !ok {
+			return g
+		}
+		for i := range min(len(arr), rv.Len()) {
+			arr[i] = rewriteEncoded(rv.Index(i), arr[i], leaf)
+		}
+	case reflect.Map:
+		m, ok := g.(map[string]any)
+		if !ok || rv.Type().Key().Kind() != reflect.String {
+			return g
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if val, ok := m[k]; ok {
+				m[k] = rewriteEncoded(iter.Value(), val, leaf)
+			}
+		}
+	}
+	return g
+}
+
+// rewriteDecoded walks g, a generic JSON value about to be decoded into type
+// t, and replaces the values for which leaf reports true so that they decode
+// into their Go types.
+func rewriteDecoded(t reflect.Type, g any, leaf func(reflect.Type, any) (any, bool)) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if out, ok := leaf(t, g); ok {
+		return out
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := g.(map[string]any)
+		if !ok {
+			return g
+		}
+		fields := jsonFields(t)
+		for k, val := range m {
+			for _, f := range fields {
+				if strings.EqualFold(f.name, k) {
+					m[k] = rewriteDecoded(t.FieldByIndex(f.index).Type, val, leaf)
+					break
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := g.([]any)
+		if !ok {
+			return g
+		}
+		for i, val := range arr {
+			arr[i] = rewriteDecoded(t.Elem(), val, leaf)
+		}
+	case reflect.Map:
+		m, ok := g.(map[string]any)
+		if !ok {
+			return g
+		}
+		for k, val := range m {
+			m[k] = rewriteDecoded(t.Elem(), val, leaf)
+		}
+	}
+	return g
+}
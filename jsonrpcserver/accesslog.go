@@ -0,0 +1,239 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AccessEntry describes one served request.
+type AccessEntry struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	ID       any           `json:"id,omitempty"`
+	Caller   string        `json:"caller,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// CorrelationID joins the entry with the client's logs.
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Code is the JSON-RPC error code, or zero on success.
+	Code         int  `json:"code,omitempty"`
+	Notification bool `json:"notification,omitempty"`
+	ParamsBytes  int  `json:"paramsBytes"`
+	ResultBytes  int  `json:"resultBytes"`
+}
+
+// AuditEntry records a call to a mutating method in full.
+type AuditEntry struct {
+	Time          time.Time     `json:"time"`
+	Method        string        `json:"method"`
+	ID            any           `json:"id,omitempty"`
+	Caller        string        `json:"caller,omitempty"`
+	Duration      time.Duration `json:"duration"`
+	CorrelationID string        `json:"correlationId,omitempty"`
+	// Params are the request params with redacted fields replaced.
+	Params json.RawMessage `json:"params,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// AccessSink receives access log entries.
+type AccessSink interface {
+	LogAccess(ctx context.Context, e AccessEntry)
+}
+
+// AuditSink receives audit entries.
+type AuditSink interface {
+	LogAudit(ctx context.Context, e AuditEntry)
+}
+
+// AccessSinkFunc adapts a function to the AccessSink interface.
+type AccessSinkFunc func(ctx context.Context, e AccessEntry)
+
+// LogAccess calls f.
+func (f AccessSinkFunc) LogAccess(ctx context.Context, e AccessEntry) { f(ctx, e) }
+
+// AuditSinkFunc adapts a function to the AuditSink interface.
+type AuditSinkFunc func(ctx context.Context, e AuditEntry)
+
+// LogAudit calls f.
+func (f AuditSinkFunc) LogAudit(ctx context.Context, e AuditEntry) { f(ctx, e) }
+
+// SlogSink writes access and audit entries to a slog.Logger at info level.
+type SlogSink struct {
+	Logger *slog.Logger
+}
+
+// LogAccess implements AccessSink.
+func (s SlogSink) LogAccess(ctx context.Context, e AccessEntry) {
+	s.Logger.InfoContext(ctx, "rpc access",
+		"method", e.Method, "id", e.ID, "caller", e.Caller, "duration", e.Duration,
+		"correlation_id", e.CorrelationID, "code", e.Code, "notification", e.Notification,
+		"params_bytes", e.ParamsBytes, "result_bytes", e.ResultBytes)
+}
+
+// LogAudit implements AuditSink.
+func (s SlogSink) LogAudit(ctx context.Context, e AuditEntry) {
+	attrs := []any{"method", e.Method, "id", e.ID, "caller", e.Caller, "duration", e.Duration,
+		"correlation_id", e.CorrelationID, "params", string(e.Params)}
+	if e.Error != nil {
+		attrs = append(attrs, "code", e.Error.Code, "error", e.Error.Message)
+	}
+	s.Logger.InfoContext(ctx, "rpc audit", attrs...)
+}
+
+// JSONLinesSink writes access and audit entries to W as one JSON object per
+// line, e.g. to a log file.
+type JSONLinesSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLinesSink creates a JSONLinesSink writing to w.
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{enc: json.NewEncoder(w)}
+}
+
+// LogAccess implements AccessSink.
+func (s *JSONLinesSink) LogAccess(_ context.Context, e AccessEntry) { s.write(e) }
+
+// LogAudit implements AuditSink.
+func (s *JSONLinesSink) LogAudit(_ context.Context, e AuditEntry) { s.write(e) }
+
+// write encodes v as a line.
+func (s *JSONLinesSink) write(v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.enc.Encode(v)
+}
+
+// AccessLog returns middleware that reports every request to sink with its
+// duration, caller, outcome and sizes.
+func AccessLog(sink AccessSink) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			start := time.Now()
+			result, err := next.ServeRPC(ctx, params)
+			e := AccessEntry{
+				Time:          start,
+				Caller:        callerOf(ctx),
+				Duration:      time.Since(start),
+				CorrelationID: CorrelationID(ctx),
+				ParamsBytes:   len(params),
+			}
+			if req := RequestFromContext(ctx); req != nil {
+				e.Method, e.ID, e.Notification = req.Method, req.ID, req.IsNotification()
+			}
+			if err != nil {
+				e.Code = errorCode(err)
+			} else if js, mErr := json.Marshal(result); mErr == nil {
+				e.ResultBytes = len(js)
+			}
+			sink.LogAccess(ctx, e)
+			return result, err
+		})
+	}
+}
+
+// AuditOpts configures the Audit middleware.
+type AuditOpts struct {
+	// Methods lists the audited methods.
+	Methods []string
+	// Mutating, when set, decides for methods not in Methods.
+	Mutating func(method string) bool
+	// Redact lists object member names whose values are replaced with
+	// "[REDACTED]" at any depth of the params.
+	Redact []string
+}
+
+// Audit returns middleware that records the full request of mutating
+// methods to sink after they run.
+func Audit(sink AuditSink, opts AuditOpts) Middleware {
+	audited := func(method string) bool {
+		return slices.Contains(opts.Methods, method) || opts.Mutating != nil && opts.Mutating(method)
+	}
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			req := RequestFromContext(ctx)
+			if req == nil || !audited(req.Method) {
+				return next.ServeRPC(ctx, params)
+			}
+			start := time.Now()
+			result, err := next.ServeRPC(ctx, params)
+			e := AuditEntry{
+				Time:          start,
+				Method:        req.Method,
+				ID:            req.ID,
+				Caller:        callerOf(ctx),
+				Duration:      time.Since(start),
+				CorrelationID: CorrelationID(ctx),
+				Params:        redact(params, opts.Redact),
+			}
+			if err != nil {
+				var rpcErr *Error
+				if errors.As(err, &rpcErr) {
+					e.Error = rpcErr
+				} else {
+					e.Error = NewError(CodeInternalError, err.Error(), nil)
+				}
+			}
+			sink.LogAudit(ctx, e)
+			return result, err
+		})
+	}
+}
+
+// callerOf identifies the caller by Principal ID, falling back to the IP.
+func callerOf(ctx context.Context) string {
+	if p := PrincipalFromContext(ctx); p != nil && p.ID != "" {
+		return p.ID
+	}
+	return KeyByIP(ctx)
+}
+
+// errorCode returns the JSON-RPC code err will most likely be sent with.
+func errorCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternalError
+}
+
+// redact replaces the values of the named members throughout params.
+func redact(params json.RawMessage, names []string) json.RawMessage {
+	if len(names) == 0 || len(params) == 0 {
+		return params
+	}
+	var g any
+	if err := json.Unmarshal(params, &g); err != nil {
+		return params
+	}
+	out, err := json.Marshal(redactValue(g, names))
+	if err != nil {
+		return params
+	}
+	return out
+}
+
+// redactValue walks a generic JSON value replacing the named members.
+func redactValue(g any, names []string) any {
+	switch v := g.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if slices.Contains(names, k) {
+				v[k] = "[REDACTED]"
+			} else {
+				v[k] = redactValue(item, names)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item, names)
+		}
+	}
+	return g
+}
@@ -0,0 +1,57 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// admission bounds the handlers executing at once and the requests waiting
+// for one to finish.
+type admission struct {
+	slots    chan struct{}
+	queued   atomic.Int64
+	maxQueue int64
+}
+
+// newAdmission returns nil when workers is not positive.
+func newAdmission(workers, maxQueue int) *admission {
+	if workers <= 0 {
+		return nil
+	}
+	return &admission{slots: make(chan struct{}, workers), maxQueue: int64(maxQueue)}
+}
+
+// acquire takes a worker slot, waiting in the queue when all are busy. It
+// fails with CodeBusy when the queue is full.
+func (a *admission) acquire(ctx context.Context) error {
+	select {
+	case a.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if a.queued.Add(1) > a.maxQueue {
+		a.queued.Add(-1)
+		return NewError(CodeBusy, "server busy", nil)
+	}
+	defer a.queued.Add(-1)
+	select {
+	case a.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release returns a worker slot.
+func (a *admission) release() {
+	<-a.slots
+}
+
+// Load returns the number of handlers executing and the requests queued for
+// a worker. Both are zero when MuxOpts.Workers is not set.
+func (m *Mux) Load() (active, queued int) {
+	if m.admission == nil {
+		return 0, 0
+	}
+	return len(m.admission.slots), int(m.admission.queued.Load())
+}
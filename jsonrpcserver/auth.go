@@ -0,0 +1,361 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string
+	Scopes []string
+	// Claims holds validator-specific details such as JWT claims.
+	Claims map[string]any
+}
+
+// HasScope reports whether p was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+// principalKey is the context key under which the Principal is stored.
+type principalKey struct{}
+
+// PrincipalFromContext returns the authenticated caller, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// Authenticator validates the credentials of an HTTP request. It returns a
+// nil Principal and nil error when the request carries none of the
+// credentials it understands, and an error when they are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+// Authenticate calls f.
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) { return f(r) }
+
+// AuthOpts configures the Authenticate wrapper.
+type AuthOpts struct {
+	// Authenticators are tried in order; the first to recognize credentials
+	// decides.
+	Authenticators []Authenticator
+	// Optional lets requests without credentials through unauthenticated,
+	// leaving the decision to RequireScopes or the handlers.
+	Optional bool
+}
+
+// Authenticate wraps h so that every request is authenticated before it is
+// decoded. The Principal is placed in the request context; requests without
+// valid credentials get HTTP 401 and a CodeUnauthorized error, and bodies
+// too large to check get HTTP 413.
+func Authenticate(h http.Handler, opts AuthOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, a := range opts.Authenticators {
+			p, err := a.Authenticate(r)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeTooLarge(w, tooLarge.Limit)
+					return
+				}
+				writeUnauthorized(w, err.Error())
+				return
+			}
+			if p != nil {
+				h.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+				return
+			}
+		}
+		if !opts.Optional {
+			writeUnauthorized(w, "missing credentials")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// writeUnauthorized rejects a request that failed authentication.
+func writeUnauthorized(w http.ResponseWriter, reason string) {
+	writeResponse(w, http.StatusUnauthorized, &Response{Error: NewError(CodeUnauthorized, "unauthorized", reason)})
+}
+
+// BearerAuth authenticates "Authorization: Bearer" tokens with Validate.
+type BearerAuth struct {
+	Validate func(ctx context.Context, token string) (*Principal, error)
+}
+
+// Authenticate implements Authenticator.
+func (a *BearerAuth) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, nil
+	}
+	return a.Validate(r.Context(), token)
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// JWTAuth authenticates bearer JWTs signed with HS256, HS384, HS512 or
+// RS256. The sub claim becomes the Principal ID and the scope (space
+// separated) or scopes claim its scopes.
+type JWTAuth struct {
+	// Secret verifies HMAC-signed tokens.
+	Secret []byte
+	// PublicKey verifies RS256-signed tokens.
+	PublicKey *rsa.PublicKey
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// Authenticate implements Authenticator.
+func (a *JWTAuth) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, nil
+	}
+	return a.Verify(token)
+}
+
+// Verify checks the signature and claims of token.
+func (a *JWTAuth) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+	if err := a.verifySignature(header.Alg, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := a.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	p := &Principal{Claims: claims}
+	p.ID, _ = claims["sub"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		p.Scopes = strings.Fields(scope)
+	}
+	if scopes, ok := claims["scopes"].([]any); ok {
+		for _, s := range scopes {
+			if s, ok := s.(string); ok {
+				p.Scopes = append(p.Scopes, s)
+			}
+		}
+	}
+	return p, nil
+}
+
+// verifySignature checks sig over signed with the algorithm alg.
+func (a *JWTAuth) verifySignature(alg, signed string, sig []byte) error {
+	var newHash func() hash.Hash
+	switch alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	case "RS256":
+		if a.PublicKey == nil {
+			return errors.New("token algorithm RS256 is not accepted")
+		}
+		sum := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(a.PublicKey, crypto.SHA256, sum[:], sig) != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("token algorithm %q is not accepted", alg)
+	}
+	if len(a.Secret) == 0 {
+		return fmt.Errorf("token algorithm %s is not accepted", alg)
+	}
+	mac := hmac.New(newHash, a.Secret)
+	mac.Write([]byte(signed))
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return errors.New("invalid token signature")
+	}
+	return nil
+}
+
+// checkClaims validates the time, issuer and audience claims.
+func (a *JWTAuth) checkClaims(claims map[string]any) error {
+	now := time.Now()
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(a.Leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token not yet valid")
+	}
+	if a.Issuer != "" && claims["iss"] != a.Issuer {
+		return errors.New("token issuer mismatch")
+	}
+	if a.Audience != "" {
+		switch aud := claims["aud"].(type) {
+		case string:
+			if aud == a.Audience {
+				return nil
+			}
+		case []any:
+			if slices.Contains(aud, any(a.Audience)) {
+				return nil
+			}
+		}
+		return errors.New("token audience mismatch")
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT into v.
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// APIKeyAuth authenticates requests by an API key header.
+type APIKeyAuth struct {
+	// Header defaults to "X-API-Key".
+	Header string
+	// Lookup returns the Principal owning key, or an error for unknown keys.
+	Lookup func(ctx context.Context, key string) (*Principal, error)
+}
+
+// Authenticate implements Authenticator.
+func (a *APIKeyAuth) Authenticate(r *http.Request) (*Principal, error) {
+	header := a.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+	key := r.Header.Get(header)
+	if key == "" {
+		return nil, nil
+	}
+	return a.Lookup(r.Context(), key)
+}
+
+// HMACAuth authenticates requests signed with a shared secret. The client
+// sends its key ID, a Unix timestamp and the hex HMAC-SHA256 of
+// "timestamp.body" in the KeyIDHeader, TimestampHeader and SignatureHeader.
+type HMACAuth struct {
+	// Secret returns the secret and Principal of keyID, or an error for
+	// unknown keys.
+	Secret func(ctx context.Context, keyID string) ([]byte, *Principal, error)
+	// Header names default to "X-Key-Id", "X-Timestamp" and "X-Signature".
+	KeyIDHeader     string
+	TimestampHeader string
+	SignatureHeader string
+	// MaxSkew bounds the age of the timestamp; it defaults to five minutes.
+	MaxSkew time.Duration
+	// NonceHeader, when set, names a header whose value is signed too, as
+	// "timestamp.nonce.body", so that RejectReplays can trust it.
+	NonceHeader string
+	// MaxBodyBytes bounds the signed body; zero means 1 MiB. Larger bodies
+	// are answered with HTTP 413.
+	MaxBodyBytes int64
+}
+
+// Authenticate implements Authenticator. It reads the body and restores it
+// for the next handler.
+func (a *HMACAuth) Authenticate(r *http.Request) (*Principal, error) {
+	sigHex := r.Header.Get(orDefault(a.SignatureHeader, "X-Signature"))
+	if sigHex == "" {
+		return nil, nil
+	}
+	keyID := r.Header.Get(orDefault(a.KeyIDHeader, "X-Key-Id"))
+	ts := r.Header.Get(orDefault(a.TimestampHeader, "X-Timestamp"))
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid signature timestamp")
+	}
+	skew := a.MaxSkew
+	if skew <= 0 {
+		skew = 5 * time.Minute
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > skew || d < -skew {
+		return nil, errors.New("signature timestamp out of range")
+	}
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	secret, p, err := a.Secret(r.Context(), keyID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := peekBody(nil, r, a.MaxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts + "."))
+	if a.NonceHeader != "" {
+		mac.Write([]byte(r.Header.Get(a.NonceHeader) + "."))
+	}
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return nil, errors.New("invalid signature")
+	}
+	if p == nil {
+		p = &Principal{ID: keyID}
+	}
+	return p, nil
+}
+
+// orDefault returns v, or def when v is empty.
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
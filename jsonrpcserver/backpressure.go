@@ -0,0 +1,109 @@
+package jsonrpcserver
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// BackpressurePolicy selects what happens to a pushed notification when a
+// session's send queue is full. Responses to requests always wait for room.
+type BackpressurePolicy int
+
+const (
+	// DropNewest discards the notification being pushed.
+	DropNewest BackpressurePolicy = iota
+	// DropOldest discards the oldest queued notification to make room.
+	DropOldest
+	// Disconnect closes the session of the slow client.
+	Disconnect
+	// Block waits up to MuxOpts.BackpressureTimeout for room, then drops
+	// the notification.
+	Block
+)
+
+// defaultBackpressureTimeout is used by Block when no timeout is configured.
+const defaultBackpressureTimeout = time.Second
+
+// HubStats counts the effects of backpressure on a Hub's sessions.
+type HubStats struct {
+	Sessions     int
+	Dropped      uint64
+	Disconnected uint64
+}
+
+// hubCounters holds the running totals behind HubStats.
+type hubCounters struct {
+	dropped      atomic.Uint64
+	disconnected atomic.Uint64
+}
+
+// Stats returns the number of connected sessions and the notifications
+// dropped and sessions disconnected by backpressure so far.
+func (h *Hub) Stats() HubStats {
+	return HubStats{
+		Sessions:     h.Len(),
+		Dropped:      h.counters.dropped.Load(),
+		Disconnected: h.counters.disconnected.Load(),
+	}
+}
+
+// Dropped returns the number of notifications dropped for s.
+func (s *Session) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// trySend queues a pushed notification, applying the backpressure policy
+// when the send queue is full. It reports whether data was queued.
+func (s *Session) trySend(data []byte) bool {
+	if s.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case s.out <- data:
+		return true
+	default:
+	}
+	switch s.policy {
+	case DropOldest:
+		for {
+			select {
+			case s.out <- data:
+				return true
+			default:
+			}
+			select {
+			case <-s.out:
+				s.drop()
+			default:
+			}
+		}
+	case Disconnect:
+		s.drop()
+		s.counters.disconnected.Add(1)
+		s.conn.closeWith(1008, "slow consumer")
+		s.conn.conn.Close()
+		return false
+	case Block:
+		timeout := s.blockTimeout
+		if timeout <= 0 {
+			timeout = defaultBackpressureTimeout
+		}
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		select {
+		case s.out <- data:
+			return true
+		case <-t.C:
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+	s.drop()
+	return false
+}
+
+// drop counts a discarded notification.
+func (s *Session) drop() {
+	s.dropped.Add(1)
+	s.counters.dropped.Add(1)
+}
@@ -0,0 +1,185 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes one params field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// BindParams decodes the params of the request served under ctx into args,
+// which must point to a struct. Named params fill fields by JSON name and
+// positional params fill exported fields in declaration order. Fields are
+// then checked against their `validate:"..."` tags, a comma-separated list
+// of required, min=N, max=N and oneof=a b c. Failures are returned as
+// -32602 Invalid params with the []FieldError in data.
+func BindParams(ctx context.Context, args any) error {
+	var params json.RawMessage
+	if req := RequestFromContext(ctx); req != nil {
+		params = req.Params
+	}
+	rv := reflect.ValueOf(args)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("jsonrpcserver: BindParams needs a pointer to a struct, got %T", args)
+	}
+	if err := bindInto(params, rv.Elem()); err != nil {
+		return ErrInvalidParams(err.Error())
+	}
+	if errs := validateStruct(rv.Elem()); len(errs) > 0 {
+		return ErrInvalidParams(errs)
+	}
+	if v, ok := args.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return ErrInvalidParams(err.Error())
+		}
+	}
+	return nil
+}
+
+// bindInto decodes named or positional params into the struct sv.
+func bindInto(params json.RawMessage, sv reflect.Value) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if params[0] != '[' {
+		return json.Unmarshal(params, sv.Addr().Interface())
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(params, &items); err != nil {
+		return err
+	}
+	fields := exportedFields(sv.Type())
+	if len(items) > len(fields) {
+		return fmt.Errorf("got %d params, want at most %d", len(items), len(fields))
+	}
+	for i, item := range items {
+		if err := json.Unmarshal(item, sv.Field(fields[i]).Addr().Interface()); err != nil {
+			return fmt.Errorf("param %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// exportedFields returns the indexes of the exported fields of struct type t.
+func exportedFields(t reflect.Type) []int {
+	var out []int
+	for i := range t.NumField() {
+		if t.Field(i).IsExported() {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// validateArgs checks the validate tags of v when it is a struct or a
+// non-nil pointer to one.
+func validateArgs(v reflect.Value) []FieldError {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return validateStruct(v)
+}
+
+// validateStruct checks the validate tags of sv's fields.
+func validateStruct(sv reflect.Value) []FieldError {
+	var errs []FieldError
+	t := sv.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("validate")
+		if tag == "" || !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if jn, _, _ := strings.Cut(f.Tag.Get("json"), ","); jn != "" && jn != "-" {
+			name = jn
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			if msg := checkRule(sv.Field(i), rule); msg != "" {
+				key, _, _ := strings.Cut(rule, "=")
+				errs = append(errs, FieldError{Field: name, Rule: key, Message: msg})
+			}
+		}
+	}
+	return errs
+}
+
+// checkRule applies one validation rule to v and returns a message on failure.
+func checkRule(v reflect.Value, rule string) string {
+	key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+	if key == "required" {
+		if v.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch key {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return "invalid rule " + rule
+		}
+		n, isLen, err := measure(v)
+		if err != nil {
+			return err.Error()
+		}
+		what := "value"
+		if isLen {
+			what = "length"
+		}
+		if key == "min" && n < limit {
+			return fmt.Sprintf("%s must be at least %s", what, arg)
+		}
+		if key == "max" && n > limit {
+			return fmt.Sprintf("%s must be at most %s", what, arg)
+		}
+	case "oneof":
+		if !slices.Contains(strings.Fields(arg), fmt.Sprint(v.Interface())) {
+			return "must be one of " + arg
+		}
+	default:
+		return "unknown rule " + key
+	}
+	return ""
+}
+
+// measure returns the number compared by min and max: the value of numbers
+// and the length of strings, slices and maps.
+func measure(v reflect.Value) (float64, bool, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, nil
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true, nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, nil
+	}
+	return 0, false, errors.New("min and max need a number, string, slice or map")
+}
@@ -0,0 +1,65 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"slices"
+)
+
+// Transports a request can arrive over.
+const (
+	TransportHTTP      = "http"
+	TransportWebSocket = "websocket"
+)
+
+// sensitiveHeaders are left out of Caller.Header unless listed in
+// MuxOpts.CallerHeaders.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Caller describes the client that issued a request.
+type Caller struct {
+	RemoteAddr string
+	UserAgent  string
+	// Transport is TransportHTTP or TransportWebSocket.
+	Transport string
+	// Header holds the request headers selected by MuxOpts.CallerHeaders,
+	// or all but credentials when none are selected.
+	Header http.Header
+	// TLS is the connection state of TLS connections, or nil.
+	TLS *tls.ConnectionState
+}
+
+// callerKey is the context key under which the Caller is stored.
+type callerKey struct{}
+
+// CallerFromContext returns the caller of the request served under ctx, or
+// nil outside a Mux.
+func CallerFromContext(ctx context.Context) *Caller {
+	c, _ := ctx.Value(callerKey{}).(*Caller)
+	return c
+}
+
+// withCaller returns a copy of ctx carrying the caller of r.
+func (m *Mux) withCaller(ctx context.Context, r *http.Request, transport string) context.Context {
+	c := &Caller{
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		Transport:  transport,
+		TLS:        r.TLS,
+	}
+	if m.callerHeaders != nil {
+		c.Header = make(http.Header, len(m.callerHeaders))
+		for _, name := range m.callerHeaders {
+			if v := r.Header.Values(name); len(v) > 0 {
+				c.Header[http.CanonicalHeaderKey(name)] = slices.Clone(v)
+			}
+		}
+	} else {
+		c.Header = r.Header.Clone()
+		for _, name := range sensitiveHeaders {
+			c.Header.Del(name)
+		}
+	}
+	return context.WithValue(ctx, callerKey{}, c)
+}
@@ -0,0 +1,155 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultCompressMinBytes is the threshold used when CompressOpts.MinBytes
+// is zero.
+const defaultCompressMinBytes = 1024
+
+// CompressOpts configures response compression.
+type CompressOpts struct {
+	// MinBytes is the smallest body compressed; it defaults to 1 KiB.
+	MinBytes int
+	// Level is the gzip/flate compression level; zero means the default.
+	Level int
+}
+
+// Compress wraps h so that responses of at least opts.MinBytes are gzip or
+// deflate compressed for clients that accept it. WebSocket upgrades pass
+// through untouched.
+func Compress(h http.Handler, opts CompressOpts) http.Handler {
+	if opts.MinBytes <= 0 {
+		opts.MinBytes = defaultCompressMinBytes
+	}
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || isWebSocketUpgrade(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, opts: opts}
+		defer cw.finish()
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// acceptedEncoding picks gzip or deflate from an Accept-Encoding header.
+func acceptedEncoding(header string) string {
+	deflate := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(strings.TrimSpace(params), " ", "") == "q=0" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+	if deflate {
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter buffers the start of a response and compresses it once it
+// reaches the threshold.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	opts     CompressOpts
+
+	status      int
+	buf         bytes.Buffer
+	zw          io.WriteCloser
+	passthrough bool
+}
+
+// WriteHeader defers the status until the encoding is decided.
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers p until the threshold is reached, then compresses.
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	if w.zw != nil {
+		return w.zw.Write(p)
+	}
+	w.buf.Write(p)
+	if w.buf.Len() < w.opts.MinBytes {
+		return len(p), nil
+	}
+	if err := w.startCompression(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// startCompression switches to compressed output and flushes the buffer.
+func (w *compressWriter) startCompression() error {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.statusOrOK())
+		_, err := w.ResponseWriter.Write(w.buf.Bytes())
+		return err
+	}
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.statusOrOK())
+	var err error
+	if w.encoding == "gzip" {
+		w.zw, err = gzip.NewWriterLevel(w.ResponseWriter, w.opts.Level)
+	} else {
+		w.zw, err = flate.NewWriter(w.ResponseWriter, w.opts.Level)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.zw.Write(w.buf.Bytes())
+	return err
+}
+
+// statusOrOK returns the deferred status, defaulting to 200.
+func (w *compressWriter) statusOrOK() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// finish completes the compressed stream or writes a small body as is.
+func (w *compressWriter) finish() {
+	switch {
+	case w.zw != nil:
+		w.zw.Close()
+	case !w.passthrough:
+		if w.status != 0 || w.buf.Len() > 0 {
+			w.ResponseWriter.WriteHeader(w.statusOrOK())
+		}
+		w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
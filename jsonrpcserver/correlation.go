@@ -0,0 +1,72 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"maps"
+	"net/http"
+)
+
+// CorrelationHeader carries the correlation ID of a request. A valid ID
+// sent by the client is kept; otherwise one is generated. It is echoed in
+// the response either way.
+const CorrelationHeader = "X-Correlation-ID"
+
+// maxCorrelationID bounds the length of accepted correlation IDs.
+const maxCorrelationID = 128
+
+// correlationKey is the context key under which the correlation ID is stored.
+type correlationKey struct{}
+
+// CorrelationID returns the correlation ID of the request served under ctx,
+// or "".
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// withCorrelationID returns a copy of ctx carrying id.
+func withCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// correlate takes the correlation ID of r, generating one when it is absent
+// or invalid, and echoes it in the response headers.
+func correlate(w http.ResponseWriter, r *http.Request) context.Context {
+	id := r.Header.Get(CorrelationHeader)
+	if !validCorrelationID(id) {
+		id = newID()
+	}
+	w.Header().Set(CorrelationHeader, id)
+	return withCorrelationID(r.Context(), id)
+}
+
+// validCorrelationID accepts short IDs of printable ASCII without spaces.
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationID {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// withCorrelationData returns a copy of e whose data carries the
+// correlation ID, when the data is absent or an object.
+func withCorrelationData(e *Error, id string) *Error {
+	if id == "" {
+		return e
+	}
+	cp := *e
+	switch data := e.Data.(type) {
+	case nil:
+		cp.Data = map[string]any{"correlationId": id}
+	case map[string]any:
+		m := maps.Clone(data)
+		m["correlationId"] = id
+		cp.Data = m
+	}
+	return &cp
+}
@@ -0,0 +1,80 @@
+package jsonrpcserver
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOpts configures cross-origin access to a JSON-RPC endpoint.
+type CORSOpts struct {
+	// AllowedOrigins lists the origins allowed to call the endpoint; "*"
+	// allows any origin, though never with credentials.
+	AllowedOrigins []string
+	// AllowOriginFunc, when set, decides for origins not in AllowedOrigins.
+	AllowOriginFunc func(origin string) bool
+	// AllowedHeaders are the request headers browsers may send; Content-Type
+	// is always allowed.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication
+	// from the origins listed explicitly or accepted by AllowOriginFunc.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// CORS wraps h with CORS handling: preflight requests are answered directly
+// and responses to allowed origins carry the Access-Control headers.
+func CORS(h http.Handler, opts CORSOpts) http.Handler {
+	allowHeaders := strings.Join(append([]string{"Content-Type"}, opts.AllowedHeaders...), ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	explicit := func(origin string) bool {
+		return slices.Contains(opts.AllowedOrigins, origin) ||
+			opts.AllowOriginFunc != nil && opts.AllowOriginFunc(origin)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		w.Header().Add("Vary", "Origin")
+		named := origin != "" && explicit(origin)
+		if origin == "" || !named && !anyOrigin {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		// Browsers refuse credentials with "*"; reflecting an origin allowed
+		// only through it must not grant them either.
+		credentials := opts.AllowCredentials && named
+		if anyOrigin && !credentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if exposeHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", http.MethodPost+", "+http.MethodOptions)
+		w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+		if opts.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
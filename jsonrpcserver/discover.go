@@ -0,0 +1,210 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Names of the built-in introspection methods. Handlers registered under
+// these names take precedence.
+const (
+	DiscoverMethod = "rpc.discover"
+	MethodsMethod  = "rpc.methods"
+)
+
+// OpenRPCDocument is an OpenRPC service description.
+type OpenRPCDocument struct {
+	OpenRPC string          `json:"openrpc"`
+	Info    OpenRPCInfo     `json:"info"`
+	Methods []OpenRPCMethod `json:"methods"`
+}
+
+// OpenRPCInfo holds the title and version of an OpenRPC document.
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes one method of an OpenRPC document.
+type OpenRPCMethod struct {
+	Name           string                     `json:"name"`
+	ParamStructure string                     `json:"paramStructure,omitempty"`
+	Params         []OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor  `json:"result,omitempty"`
+}
+
+// OpenRPCContentDescriptor describes a param or result with a JSON Schema.
+type OpenRPCContentDescriptor struct {
+	Name     string         `json:"name"`
+	Required bool           `json:"required,omitempty"`
+	Schema   map[string]any `json:"schema"`
+}
+
+// describer is implemented by handlers that know their param and result types.
+type describer interface {
+	describe(name string) OpenRPCMethod
+}
+
+// builtin returns the handler of a built-in introspection method, or nil.
+func (m *Mux) builtin(method string) Handler {
+	switch method {
+	case DiscoverMethod:
+		return HandlerFunc(func(ctx context.Context, _ json.RawMessage) (any, error) {
+			return m.Discover(), nil
+		})
+	case MethodsMethod:
+		return HandlerFunc(func(ctx context.Context, _ json.RawMessage) (any, error) {
+			return slices.Sorted(maps.Keys(m.allHandlers())), nil
+		})
+	}
+	return nil
+}
+
+// Discover returns the OpenRPC document describing the registered methods,
+// including those of mounted Muxes. Params and results are derived from the
+// handler types when they were registered through Register, RegisterService
+// or HandleFunction.
+func (m *Mux) Discover() *OpenRPCDocument {
+	doc := &OpenRPCDocument{OpenRPC: "1.2.6", Info: m.info, Methods: []OpenRPCMethod{}}
+	handlers := m.allHandlers()
+	for _, name := range slices.Sorted(maps.Keys(handlers)) {
+		if d, ok := handlers[name].(describer); ok {
+			doc.Methods = append(doc.Methods, d.describe(name))
+		} else {
+			doc.Methods = append(doc.Methods, OpenRPCMethod{Name: name, Params: []OpenRPCContentDescriptor{}})
+		}
+	}
+	return doc
+}
+
+// allHandlers returns every handler reachable from m by full method name.
+func (m *Mux) allHandlers() map[string]Handler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := maps.Clone(m.handlers)
+	for prefix, sub := range m.mounts {
+		for name, h := range sub.allHandlers() {
+			if _, ok := out[prefix+"."+name]; !ok {
+				out[prefix+"."+name] = h
+			}
+		}
+	}
+	return out
+}
+
+// describeArgs documents a method taking a single args value: the fields of
+// a struct become named params, anything else a single positional param.
+func describeArgs(name string, args, result reflect.Type) OpenRPCMethod {
+	doc := OpenRPCMethod{Name: name, Result: resultDescriptor(result)}
+	t := args
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		doc.Params = []OpenRPCContentDescriptor{{Name: "args", Required: true, Schema: schemaFor(args)}}
+		return doc
+	}
+	doc.ParamStructure = "by-name"
+	for _, f := range jsonFields(t) {
+		doc.Params = append(doc.Params, OpenRPCContentDescriptor{Name: f.name, Required: f.required, Schema: schemaFor(f.typ)})
+	}
+	if doc.Params == nil {
+		doc.Params = []OpenRPCContentDescriptor{}
+	}
+	return doc
+}
+
+// resultDescriptor documents a result type.
+func resultDescriptor(t reflect.Type) *OpenRPCContentDescriptor {
+	return &OpenRPCContentDescriptor{Name: "result", Schema: schemaFor(t)}
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// jsonField is a struct field as encoding/json sees it.
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+// jsonFields lists the exported fields of struct type t under their JSON
+// names. Fields without omitempty that are not pointers count as required.
+func jsonFields(t reflect.Type) []jsonField {
+	var out []jsonField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		required := !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer
+		out = append(out, jsonField{name: name, typ: f.Type, required: required})
+	}
+	return out
+}
+
+// schemaFor derives a JSON Schema from a Go type.
+func schemaFor(t reflect.Type) map[string]any {
+	return schemaForSeen(t, map[reflect.Type]bool{})
+}
+
+// schemaForSeen derives a JSON Schema, cutting recursion at types already
+// being described.
+func schemaForSeen(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": schemaForSeen(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForSeen(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		props := map[string]any{}
+		var required []string
+		for _, f := range jsonFields(t) {
+			props[f.name] = schemaForSeen(f.typ, seen)
+			if f.required {
+				required = append(required, f.name)
+			}
+		}
+		s := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,144 @@
+package jsonrpcserver
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// DocsMethod is one method of the documentation page.
+type DocsMethod struct {
+	OpenRPCMethod
+	// ExampleRequest and ExampleResponse are sample payloads built from the
+	// param and result schemas.
+	ExampleRequest  json.RawMessage `json:"exampleRequest"`
+	ExampleResponse json.RawMessage `json:"exampleResponse"`
+}
+
+// DocsPage is the content of the documentation page.
+type DocsPage struct {
+	Info    OpenRPCInfo  `json:"info"`
+	Methods []DocsMethod `json:"methods"`
+}
+
+// Docs builds the documentation of the registered methods.
+func (m *Mux) Docs() *DocsPage {
+	doc := m.Discover()
+	page := &DocsPage{Info: doc.Info, Methods: make([]DocsMethod, len(doc.Methods))}
+	for i, method := range doc.Methods {
+		req := map[string]any{"jsonrpc": "2.0", "id": 1, "method": method.Name}
+		if len(method.Params) > 0 {
+			if method.ParamStructure == "by-name" {
+				named := make(map[string]any, len(method.Params))
+				for _, p := range method.Params {
+					named[p.Name] = exampleFor(p.Schema, 0)
+				}
+				req["params"] = named
+			} else {
+				positional := make([]any, len(method.Params))
+				for j, p := range method.Params {
+					positional[j] = exampleFor(p.Schema, 0)
+				}
+				req["params"] = positional
+			}
+		}
+		resp := map[string]any{"id": 1, "result": nil}
+		if method.Result != nil {
+			resp["result"] = exampleFor(method.Result.Schema, 0)
+		}
+		reqJS, _ := json.MarshalIndent(req, "", "  ")
+		respJS, _ := json.MarshalIndent(resp, "", "  ")
+		page.Methods[i] = DocsMethod{OpenRPCMethod: method, ExampleRequest: reqJS, ExampleResponse: respJS}
+	}
+	return page
+}
+
+// exampleFor builds a sample value for a schema derived by rpc.discover.
+func exampleFor(s map[string]any, depth int) any {
+	if depth > 5 {
+		return nil
+	}
+	switch s["type"] {
+	case "boolean":
+		return true
+	case "integer":
+		return 0
+	case "number":
+		return 0.5
+	case "string":
+		if s["format"] == "date-time" {
+			return "2024-01-01T00:00:00Z"
+		}
+		return "string"
+	case "array":
+		items, _ := s["items"].(map[string]any)
+		return []any{exampleFor(items, depth+1)}
+	case "object":
+		out := map[string]any{}
+		props, _ := s["properties"].(map[string]any)
+		for name, p := range props {
+			ps, _ := p.(map[string]any)
+			out[name] = exampleFor(ps, depth+1)
+		}
+		return out
+	}
+	return nil
+}
+
+// DocsHandler serves the documentation page: HTML for browsers, or the
+// DocsPage as JSON for requests accepting application/json or passing
+// ?format=json.
+func (m *Mux) DocsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := m.Docs()
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(page)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = docsTemplate.Execute(w, page)
+	})
+}
+
+// docsTemplate renders a DocsPage as HTML.
+var docsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"schema": func(s map[string]any) string {
+		js, _ := json.MarshalIndent(s, "", "  ")
+		return string(js)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Info.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; color: #222; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: .2em; }
+pre { background: #f6f8fa; padding: .8em; overflow-x: auto; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: .3em .6em; text-align: left; vertical-align: top; }
+nav a { margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>{{.Info.Title}} <small>{{.Info.Version}}</small></h1>
+<nav>{{range .Methods}}<a href="#{{.Name}}">{{.Name}}</a>{{end}}</nav>
+{{range .Methods}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+{{if .Params}}
+<table>
+<tr><th>Param</th><th>Required</th><th>Schema</th></tr>
+{{range .Params}}<tr><td>{{.Name}}</td><td>{{if .Required}}yes{{end}}</td><td><pre>{{schema .Schema}}</pre></td></tr>
+{{end}}
+</table>
+{{else}}<p>No params.</p>{{end}}
+{{if .Result}}<h3>Result</h3><pre>{{schema .Result.Schema}}</pre>{{end}}
+<h3>Example</h3>
+<pre>{{printf "%s" .ExampleRequest}}</pre>
+<pre>{{printf "%s" .ExampleResponse}}</pre>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,90 @@
+package jsonrpcserver
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrorDetails is the error data sent when MuxOpts.DetailedErrors is set
+// and the handler's error carries no data of its own.
+type ErrorDetails struct {
+	// Type is the Go type of the innermost error in the chain.
+	Type    string       `json:"type"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	// Stack is only sent in development mode.
+	Stack string `json:"stack,omitempty"`
+}
+
+// FieldErrors is an error listing params fields that failed validation.
+// Returned by a handler, it becomes an invalid params error carrying the
+// fields.
+type FieldErrors []FieldError
+
+// Error implements the error interface for FieldErrors.
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, f := range fe {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// stackError is an error annotated with the stack where it was created.
+type stackError struct {
+	err   error
+	stack string
+}
+
+// Error implements the error interface for stackError.
+func (e *stackError) Error() string { return e.err.Error() }
+
+// Unwrap returns the annotated error.
+func (e *stackError) Unwrap() error { return e.err }
+
+// WithStack annotates err with the current stack, which is sent as part of
+// ErrorDetails in development mode.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{err: err, stack: string(debug.Stack())}
+}
+
+// details describes err for the error data.
+func (m *Mux) details(err error) ErrorDetails {
+	inner := err
+	for {
+		next := errors.Unwrap(inner)
+		if next == nil {
+			break
+		}
+		inner = next
+	}
+	d := ErrorDetails{Type: fmt.Sprintf("%T", inner), Message: err.Error()}
+	var fields FieldErrors
+	if errors.As(err, &fields) {
+		d.Fields = fields
+	}
+	var se *stackError
+	if m.devMode && errors.As(err, &se) {
+		d.Stack = se.stack
+	}
+	return d
+}
+
+// detailed attaches ErrorDetails of cause to e when detailed errors are
+// enabled and e has no data beyond the message set by WrapError.
+func (m *Mux) detailed(e *Error, cause error) *Error {
+	if !m.detailedErrors || cause == nil {
+		return e
+	}
+	if _, wrapped := e.Data.(string); e.Data != nil && !(wrapped && e.cause != nil) {
+		return e
+	}
+	cp := *e
+	cp.Data = m.details(cause)
+	return &cp
+}
@@ -0,0 +1,100 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// Standard JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Implementation-defined server error codes.
+const (
+	CodeTimeout       = -32001
+	CodeCanceled      = -32002
+	CodeUnavailable   = -32003
+	CodeNotFound      = -32004
+	CodeLimitExceeded = -32005
+	CodeUnauthorized  = -32006
+	CodeForbidden     = -32007
+	CodeBusy          = -32008
+	CodeQuotaExceeded = -32009
+)
+
+// Error is a JSON-RPC error object. Handlers return it to control the code
+// and data sent to the client; any other error becomes an internal error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+
+	cause error
+}
+
+// Error implements the error interface for Error.
+func (e *Error) Error() string {
+	return strconv.Itoa(e.Code) + ": " + e.Message
+}
+
+// NewError creates an Error with the given code, message and optional data.
+func NewError(code int, message string, data any) *Error {
+	return &Error{Code: code, Message: message, Data: data}
+}
+
+// ErrInvalidParams returns an invalid params error carrying data.
+func ErrInvalidParams(data any) *Error {
+	return NewError(CodeInvalidParams, "invalid params", data)
+}
+
+// Unwrap returns the error wrapped by WrapError, if any.
+func (e *Error) Unwrap() error { return e.cause }
+
+// WrapError creates an Error with the given code and message that carries
+// err as its data and unwraps to it.
+func WrapError(code int, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Data: err.Error(), cause: err}
+}
+
+// WrapNotFound wraps err as a not found error.
+func WrapNotFound(err error) *Error {
+	return WrapError(CodeNotFound, "not found", err)
+}
+
+// ErrorMapper translates a handler error into the Error sent to the client.
+// Returning nil falls back to the default mapping.
+type ErrorMapper func(error) *Error
+
+// mapError converts a handler error into the Error sent to the client: an
+// *Error anywhere in the chain is used as is, then the ErrorMapper is
+// consulted, FieldErrors become invalid params, and context errors and
+// everything else get default codes. Internal errors carry no data, so
+// their text never reaches clients, unless DetailedErrors is set: then
+// errors without data of their own carry ErrorDetails.
+func (m *Mux) mapError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return m.detailed(e, e.cause)
+	}
+	if m.errorMapper != nil {
+		if mapped := m.errorMapper(err); mapped != nil {
+			return m.detailed(mapped, err)
+		}
+	}
+	var fields FieldErrors
+	switch {
+	case errors.As(err, &fields):
+		return &Error{Code: CodeInvalidParams, Message: "invalid params", Data: []FieldError(fields), cause: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return m.detailed(WrapError(CodeTimeout, "request timed out", err), err)
+	case errors.Is(err, context.Canceled):
+		return m.detailed(WrapError(CodeCanceled, "request canceled", err), err)
+	}
+	return m.detailed(&Error{Code: CodeInternalError, Message: "internal error", cause: err}, err)
+}
@@ -0,0 +1,189 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// fanOut gathers the members of a gateway batch that are forwarded, so that
+// those bound for the same backend are sent together. It flushes once every
+// member has either been queued or finished without forwarding.
+type fanOut struct {
+	g       *Gateway
+	ctx     context.Context
+	mu      sync.Mutex
+	pending int
+	queues  map[*Upstream][]*fanCall
+	// sem bounds the sub-batches sent at once.
+	sem chan struct{}
+}
+
+// fanCall is a member queued for a backend.
+type fanCall struct {
+	req    upstreamRequest
+	notify bool
+	result json.RawMessage
+	err    error
+	done   chan struct{}
+}
+
+// States of a fanEntry.
+const (
+	entryActive = iota
+	entryQueued
+	entryLeft
+)
+
+// fanEntry tracks one member of a fanned-out batch.
+type fanEntry struct {
+	f     *fanOut
+	state int
+}
+
+// fanEntryKey is the context key under which the fanEntry of a batch member
+// is stored.
+type fanEntryKey struct{}
+
+// fanEntryFromContext returns the batch member served under ctx, or nil.
+func fanEntryFromContext(ctx context.Context) *fanEntry {
+	e, _ := ctx.Value(fanEntryKey{}).(*fanEntry)
+	return e
+}
+
+// newFanOut returns a fanOut for a batch of n members served under ctx,
+// sending up to MuxOpts.BatchConcurrency sub-batches at once.
+func (g *Gateway) newFanOut(ctx context.Context, n int) *fanOut {
+	return &fanOut{
+		g:       g,
+		ctx:     ctx,
+		pending: n,
+		queues:  make(map[*Upstream][]*fanCall),
+		sem:     make(chan struct{}, max(g.batchConcurrency, 1)),
+	}
+}
+
+// enter returns the context for serving one member and the func to call
+// once it is served.
+func (f *fanOut) enter(ctx context.Context) (context.Context, func()) {
+	e := &fanEntry{f: f}
+	return context.WithValue(ctx, fanEntryKey{}, e), e.leave
+}
+
+// leave marks the member as not queueing, so that the batch need not wait
+// for it. Later forwards are sent on their own.
+func (e *fanEntry) leave() {
+	e.f.mu.Lock()
+	defer e.f.mu.Unlock()
+	if e.state == entryActive {
+		e.state = entryLeft
+		e.f.release()
+	}
+}
+
+// release counts down a member that is queued or done, sending the queued
+// sub-batches after the last one. f.mu must be held.
+func (f *fanOut) release() {
+	f.pending--
+	if f.pending > 0 {
+		return
+	}
+	for up, calls := range f.queues {
+		go func() {
+			f.sem <- struct{}{}
+			defer func() { <-f.sem }()
+			f.g.sendBatch(f.ctx, up, calls)
+		}()
+	}
+	f.queues = nil
+}
+
+// forward queues out for up and waits for its response. Members forwarding
+// more than once, or after they were served, are sent on their own.
+func (e *fanEntry) forward(ctx context.Context, up *Upstream, out upstreamRequest, notify bool) (json.RawMessage, error) {
+	f := e.f
+	f.mu.Lock()
+	if e.state != entryActive {
+		f.mu.Unlock()
+		return f.g.send(ctx, up, out, notify)
+	}
+	e.state = entryQueued
+	call := &fanCall{req: out, notify: notify, done: make(chan struct{})}
+	f.queues[up] = append(f.queues[up], call)
+	f.release()
+	f.mu.Unlock()
+	select {
+	case <-call.done:
+		return call.result, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sendBatch sends calls to up as one batch and hands each call its
+// response. A single call is sent as a plain request.
+func (g *Gateway) sendBatch(ctx context.Context, up *Upstream, calls []*fanCall) {
+	defer func() {
+		for _, call := range calls {
+			close(call.done)
+		}
+	}()
+	if len(calls) == 1 {
+		calls[0].result, calls[0].err = g.send(ctx, up, calls[0].req, calls[0].notify)
+		return
+	}
+	reqs := make([]upstreamRequest, len(calls))
+	byID := make(map[string]*fanCall, len(calls))
+	for i, call := range calls {
+		reqs[i] = call.req
+		reqs[i].ID = nil
+		if !call.notify {
+			id := strconv.Itoa(i)
+			reqs[i].ID = json.RawMessage(id)
+			byID[id] = call
+		}
+	}
+	var raw json.RawMessage
+	if err := g.post(ctx, up, reqs, &raw, len(byID) == 0); err != nil {
+		for _, call := range calls {
+			call.err = err
+		}
+		return
+	}
+	if len(byID) == 0 {
+		return
+	}
+	var resps []upstreamResponse
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		// A single response answers a batch the backend rejected as a whole.
+		var resp upstreamResponse
+		if err := json.Unmarshal(raw, &resp); err == nil && resp.Error != nil {
+			for _, call := range byID {
+				call.err = resp.Error.toError()
+			}
+			return
+		}
+	} else if err := json.Unmarshal(raw, &resps); err != nil {
+		for _, call := range byID {
+			call.err = NewError(CodeUnavailable, "upstream unavailable", err.Error())
+		}
+		return
+	}
+	for _, resp := range resps {
+		call, ok := byID[string(bytes.TrimSpace(resp.ID))]
+		if !ok {
+			continue
+		}
+		delete(byID, string(bytes.TrimSpace(resp.ID)))
+		if resp.Error != nil {
+			call.err = resp.Error.toError()
+		} else {
+			call.result = resp.Result
+		}
+	}
+	for _, call := range byID {
+		call.err = NewError(CodeUnavailable, "upstream unavailable", "no response in batch")
+	}
+}
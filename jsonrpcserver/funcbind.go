@@ -0,0 +1,96 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// HandleFunction registers an ordinary function for method, binding
+// positional params to its arguments:
+//
+//	func(ctx context.Context, a int, b int) (float64, error)
+//
+// The context argument is optional. Params must be an array whose length
+// matches the arity; trailing pointer arguments may be omitted and are nil.
+// Each element is decoded into its argument type, and mismatches are
+// reported as invalid params. It fails if fn does not have this shape.
+func (m *Mux) HandleFunction(method string, fn any) error {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumOut() != 2 || ft.Out(1) != errorType || ft.IsVariadic() {
+		return fmt.Errorf("jsonrpcserver: %s handler must be a func(...) (Result, error), got %T", method, fn)
+	}
+	first := 0
+	if ft.NumIn() > 0 && ft.In(0) == contextType {
+		first = 1
+	}
+	args := make([]reflect.Type, 0, ft.NumIn()-first)
+	for i := first; i < ft.NumIn(); i++ {
+		args = append(args, ft.In(i))
+	}
+	required := len(args)
+	for required > 0 && args[required-1].Kind() == reflect.Pointer {
+		required--
+	}
+	m.Handle(method, funcHandler{fn: fv, withCtx: first == 1, args: args, required: required, result: ft.Out(0)})
+	return nil
+}
+
+// funcHandler calls a function registered through HandleFunction.
+type funcHandler struct {
+	fn       reflect.Value
+	withCtx  bool
+	args     []reflect.Type
+	required int
+	result   reflect.Type
+}
+
+// describe documents the function's positional params and result.
+func (h funcHandler) describe(name string) OpenRPCMethod {
+	doc := OpenRPCMethod{Name: name, ParamStructure: "by-position", Result: resultDescriptor(h.result)}
+	for i, t := range h.args {
+		doc.Params = append(doc.Params, OpenRPCContentDescriptor{
+			Name:     "arg" + strconv.Itoa(i),
+			Required: i < h.required,
+			Schema:   schemaFor(t),
+		})
+	}
+	return doc
+}
+
+// ServeRPC binds positional params to the function's arguments and calls it.
+func (h funcHandler) ServeRPC(ctx context.Context, params json.RawMessage) (any, error) {
+	var items []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &items); err != nil {
+			return nil, ErrInvalidParams("expected positional params")
+		}
+	}
+	if len(items) < h.required || len(items) > len(h.args) {
+		if h.required == len(h.args) {
+			return nil, ErrInvalidParams(fmt.Sprintf("expected %d params, got %d", len(h.args), len(items)))
+		}
+		return nil, ErrInvalidParams(fmt.Sprintf("expected %d to %d params, got %d", h.required, len(h.args), len(items)))
+	}
+	in := make([]reflect.Value, 0, len(h.args)+1)
+	if h.withCtx {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+	for i, t := range h.args {
+		arg := reflect.New(t)
+		if i < len(items) {
+			if err := json.Unmarshal(items[i], arg.Interface()); err != nil {
+				return nil, ErrInvalidParams(fmt.Sprintf("param %d: expected %v: %v", i, t, err))
+			}
+		}
+		in = append(in, arg.Elem())
+	}
+	out := h.fn.Call(in)
+	if errV := out[1]; !errV.IsNil() {
+		return nil, errV.Interface().(error)
+	}
+	return out[0].Interface(), nil
+}
@@ -0,0 +1,271 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Upstream is a JSON-RPC backend that a Gateway forwards requests to.
+type Upstream struct {
+	// URL is the HTTP endpoint of the backend.
+	URL string
+	// Client sends the forwarded requests; nil means http.DefaultClient.
+	Client *http.Client
+	// Header is set on every request forwarded to the backend.
+	Header http.Header
+	// Credentials authenticate the gateway to the backend, replacing any
+	// forwarded Authorization header.
+	Credentials UpstreamCredentials
+}
+
+// GatewayOpts configures a Gateway.
+type GatewayOpts struct {
+	// Mux configures the Mux serving the gateway endpoint.
+	Mux *MuxOpts
+	// Auth, when set, authenticates clients before their requests are
+	// served; see Authenticate. The Principal is available to
+	// UpstreamCredentials such as TokenExchange.
+	Auth *AuthOpts
+	// Routes maps method namespaces to backends: "eth" or "eth.*" forwards
+	// every method starting with "eth.". The longest namespace wins.
+	Routes map[string]*Upstream
+	// Default receives methods no route matches; nil answers them with
+	// method not found.
+	Default *Upstream
+	// ForwardHeaders lists incoming HTTP headers copied onto forwarded
+	// requests.
+	ForwardHeaders []string
+	// Cache lists idempotent methods whose successful results are cached,
+	// with their time to live; "ns.*" covers a namespace. Calls are keyed by
+	// method, a hash of their params, the authenticated principal and the
+	// values of ForwardHeaders, and concurrent misses share one upstream
+	// call.
+	Cache map[string]time.Duration
+	// CacheSize bounds the cached results; zero means 1024.
+	CacheSize int
+}
+
+// Gateway accepts JSON-RPC on one endpoint and forwards each request to the
+// backend serving its method namespace, preserving its id, result and
+// error. Methods registered on the embedded Mux are served locally and take
+// precedence; its middleware wraps forwarded calls too.
+//
+// Batches are fanned out: the members bound for the same backend are sent
+// to it as one sub-batch, numbered by position, with the sub-batches sent
+// concurrently, up to MuxOpts.BatchConcurrency at once, and the responses
+// merged back in order. The members themselves run at once so that they
+// can be grouped.
+type Gateway struct {
+	*Mux
+	routes         map[string]*Upstream
+	fallback       *Upstream
+	forwardHeaders []string
+	cache          *responseCache
+	handler        http.Handler
+}
+
+// NewGateway returns a Gateway routing as opts describes.
+func NewGateway(opts GatewayOpts) *Gateway {
+	g := &Gateway{
+		Mux:            NewMuxWithOpts(opts.Mux),
+		routes:         make(map[string]*Upstream, len(opts.Routes)),
+		fallback:       opts.Default,
+		forwardHeaders: opts.ForwardHeaders,
+		cache:          newResponseCache(opts.Cache, opts.CacheSize),
+	}
+	for ns, up := range opts.Routes {
+		g.routes[strings.TrimSuffix(ns, ".*")] = up
+	}
+	g.Mux.gateway = g
+	g.handler = g.Mux
+	if opts.Auth != nil {
+		g.handler = Authenticate(g.Mux, *opts.Auth)
+	}
+	return g
+}
+
+// ServeHTTP authenticates the client when GatewayOpts.Auth is set and
+// serves the request.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.handler.ServeHTTP(w, r)
+}
+
+// Route returns the backend serving method, or nil.
+func (g *Gateway) Route(method string) *Upstream {
+	for i := strings.LastIndexByte(method, '.'); i > 0; i = strings.LastIndexByte(method[:i], '.') {
+		if up, ok := g.routes[method[:i]]; ok {
+			return up
+		}
+	}
+	return g.fallback
+}
+
+// forwarder returns the handler forwarding method, or nil when no backend
+// serves it.
+func (g *Gateway) forwarder(method string) Handler {
+	up := g.Route(method)
+	if up == nil {
+		return nil
+	}
+	return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+		return g.forward(ctx, up, params)
+	})
+}
+
+// upstreamRequest is a request as forwarded to a backend.
+type upstreamRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// upstreamResponse is a response as received from a backend.
+type upstreamResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *upstreamError  `json:"error"`
+}
+
+// upstreamError is an error object as received from a backend; its data is
+// kept verbatim.
+type upstreamError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// toError converts e to an *Error carrying the same members.
+func (e *upstreamError) toError() *Error {
+	var data any
+	if len(e.Data) > 0 {
+		data = e.Data
+	}
+	return NewError(e.Code, e.Message, data)
+}
+
+// forward sends the request served under ctx to up with params and returns
+// its raw result or error. Members of a batch are queued for fan-out.
+func (g *Gateway) forward(ctx context.Context, up *Upstream, params json.RawMessage) (any, error) {
+	req := RequestFromContext(ctx)
+	out := upstreamRequest{JSONRPC: "2.0", Method: req.Method, Params: params}
+	notify := req.IsNotification()
+	if !notify {
+		// The id as received, which may be null.
+		out.ID = json.RawMessage(bytes.TrimSpace(req.rawID))
+	}
+	e := fanEntryFromContext(ctx)
+	send := func() (json.RawMessage, error) {
+		if e != nil {
+			return e.forward(ctx, up, out, notify)
+		}
+		return g.send(ctx, up, out, notify)
+	}
+	if g.cache == nil || notify {
+		return send()
+	}
+	ttl := g.cache.ttlFor(req.Method)
+	if ttl <= 0 {
+		return send()
+	}
+	wait := func() {}
+	if e != nil {
+		// A batch member waiting on another call must not hold up the batch.
+		wait = e.leave
+	}
+	return g.cache.do(ctx, cacheKey(req.Method, params, g.cacheScope(ctx)), ttl, wait, send)
+}
+
+// cacheScope lists what distinguishes callers whose results must not be
+// shared: the authenticated principal and the forwarded header values.
+func (g *Gateway) cacheScope(ctx context.Context) []string {
+	var scope []string
+	if p := PrincipalFromContext(ctx); p != nil {
+		scope = append(scope, p.ID)
+	} else {
+		scope = append(scope, "")
+	}
+	if in := HTTPRequestFromContext(ctx); in != nil {
+		for _, name := range g.forwardHeaders {
+			scope = append(scope, in.Header.Values(name)...)
+			scope = append(scope, "")
+		}
+	}
+	return scope
+}
+
+// send posts one request to up and returns its raw result or error.
+func (g *Gateway) send(ctx context.Context, up *Upstream, out upstreamRequest, notify bool) (json.RawMessage, error) {
+	var resp upstreamResponse
+	if err := g.post(ctx, up, out, &resp, notify); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error.toError()
+	}
+	return resp.Result, nil
+}
+
+// post sends body to up and decodes the reply into v unless discard is
+// set. Transport failures are reported as CodeUnavailable.
+func (g *Gateway) post(ctx context.Context, up *Upstream, body, v any, discard bool) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, up.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if err := g.setHeaders(ctx, r, up); err != nil {
+		return err
+	}
+	client := up.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(r)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return NewError(CodeUnavailable, "upstream unavailable", err.Error())
+	}
+	defer httpResp.Body.Close()
+	if discard {
+		io.Copy(io.Discard, httpResp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(v); err != nil {
+		return NewError(CodeUnavailable, "upstream unavailable", fmt.Sprintf("status %d: %v", httpResp.StatusCode, err))
+	}
+	return nil
+}
+
+// setHeaders sets the headers of a request forwarded to up: the forwarded
+// incoming headers, the correlation ID, the backend's own headers, then its
+// credentials.
+func (g *Gateway) setHeaders(ctx context.Context, r *http.Request, up *Upstream) error {
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json")
+	if in := HTTPRequestFromContext(ctx); in != nil {
+		for _, name := range g.forwardHeaders {
+			for _, v := range in.Header.Values(name) {
+				r.Header.Add(name, v)
+			}
+		}
+	}
+	if id := CorrelationID(ctx); id != "" {
+		r.Header.Set(CorrelationHeader, id)
+	}
+	for name, values := range up.Header {
+		r.Header[name] = values
+	}
+	return applyCredentials(ctx, r, up)
+}
@@ -0,0 +1,46 @@
+package jsonrpcserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGatewayForwardsNullID(t *testing.T) {
+	var got upstreamRequest
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("decode forwarded request %q: %v", body, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"jsonrpc":"2.0","result":"ok","id":null}`)
+	}))
+	defer backend.Close()
+	g := NewGateway(GatewayOpts{Routes: map[string]*Upstream{"eth": {URL: backend.URL}}})
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","method":"eth.x","id":null}`))
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, r)
+
+	var resp struct {
+		Result string          `json:"result"`
+		Error  *Error          `json:"error"`
+		ID     json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	if resp.Error != nil || resp.Result != "ok" {
+		t.Fatalf("response = %s, want result ok", w.Body.String())
+	}
+	if string(resp.ID) != "null" {
+		t.Errorf("response id = %s, want null", resp.ID)
+	}
+	if string(got.ID) != "null" {
+		t.Errorf("forwarded id = %s, want null", got.ID)
+	}
+}
@@ -0,0 +1,107 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UpstreamCredentials attach the gateway's own credentials to a request
+// forwarded to a backend, so end clients never hold backend secrets.
+type UpstreamCredentials interface {
+	Apply(ctx context.Context, r *http.Request) error
+}
+
+// UpstreamCredentialsFunc adapts a function to UpstreamCredentials.
+type UpstreamCredentialsFunc func(ctx context.Context, r *http.Request) error
+
+// Apply calls f.
+func (f UpstreamCredentialsFunc) Apply(ctx context.Context, r *http.Request) error { return f(ctx, r) }
+
+// ServiceToken sends token as an "Authorization: Bearer" header.
+func ServiceToken(token string) UpstreamCredentials {
+	return UpstreamCredentialsFunc(func(_ context.Context, r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// BasicCredentials sends HTTP basic authentication.
+func BasicCredentials(user, password string) UpstreamCredentials {
+	return UpstreamCredentialsFunc(func(_ context.Context, r *http.Request) error {
+		r.SetBasicAuth(user, password)
+		return nil
+	})
+}
+
+// TokenExchange trades the authenticated client's Principal for a backend
+// token, sent as an "Authorization: Bearer" header. Tokens are reused per
+// principal until they expire.
+type TokenExchange struct {
+	// Exchange returns a token for p and when it expires; a zero time means
+	// it is fetched again for every request.
+	Exchange func(ctx context.Context, p *Principal) (token string, expires time.Time, err error)
+
+	mu     sync.Mutex
+	tokens map[string]exchangedToken
+}
+
+// exchangedToken is a token obtained by a TokenExchange.
+type exchangedToken struct {
+	token   string
+	expires time.Time
+}
+
+// Apply sets the token of the principal authenticated under ctx. Requests
+// without a principal are rejected with CodeUnauthorized.
+func (x *TokenExchange) Apply(ctx context.Context, r *http.Request) error {
+	p := PrincipalFromContext(ctx)
+	if p == nil {
+		return NewError(CodeUnauthorized, "unauthorized", "no principal to exchange")
+	}
+	x.mu.Lock()
+	t, ok := x.tokens[p.ID]
+	x.mu.Unlock()
+	if !ok || !time.Now().Before(t.expires) {
+		token, expires, err := x.Exchange(ctx, p)
+		if err != nil {
+			return err
+		}
+		t = exchangedToken{token: token, expires: expires}
+		x.mu.Lock()
+		if x.tokens == nil {
+			x.tokens = make(map[string]exchangedToken)
+		}
+		now := time.Now()
+		for id, old := range x.tokens {
+			if !now.Before(old.expires) {
+				delete(x.tokens, id)
+			}
+		}
+		if now.Before(expires) {
+			x.tokens[p.ID] = t
+		}
+		x.mu.Unlock()
+	}
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return nil
+}
+
+// applyCredentials attaches up's credentials to r. Failures other than
+// *Error are reported as CodeUnavailable.
+func applyCredentials(ctx context.Context, r *http.Request, up *Upstream) error {
+	if up.Credentials == nil {
+		return nil
+	}
+	err := up.Credentials.Apply(ctx, r)
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return NewError(CodeUnavailable, "upstream credentials unavailable", err.Error())
+}
@@ -0,0 +1,155 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheSize bounds the results cached by a Gateway unless
+// GatewayOpts.CacheSize is set.
+const defaultCacheSize = 1024
+
+// responseCache holds the results of cached gateway methods. Concurrent
+// misses for the same key share one upstream call.
+type responseCache struct {
+	ttls    map[string]time.Duration
+	size    int
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	flights map[string]*cacheFlight
+}
+
+// cacheEntry is a cached result.
+type cacheEntry struct {
+	result  json.RawMessage
+	expires time.Time
+}
+
+// cacheFlight is an upstream call shared by concurrent misses.
+type cacheFlight struct {
+	done   chan struct{}
+	result json.RawMessage
+	err    error
+}
+
+// newResponseCache returns a cache for the methods in ttls, or nil when
+// there are none.
+func newResponseCache(ttls map[string]time.Duration, size int) *responseCache {
+	if len(ttls) == 0 {
+		return nil
+	}
+	c := &responseCache{
+		ttls:    make(map[string]time.Duration, len(ttls)),
+		size:    size,
+		entries: make(map[string]cacheEntry),
+		flights: make(map[string]*cacheFlight),
+	}
+	if c.size <= 0 {
+		c.size = defaultCacheSize
+	}
+	for method, ttl := range ttls {
+		c.ttls[strings.TrimSuffix(method, ".*")] = ttl
+	}
+	return c
+}
+
+// ttlFor returns the time to live of method's results, matching the method
+// before its longest namespace; zero means method is not cached.
+func (c *responseCache) ttlFor(method string) time.Duration {
+	if ttl, ok := c.ttls[method]; ok {
+		return ttl
+	}
+	for i := strings.LastIndexByte(method, '.'); i > 0; i = strings.LastIndexByte(method[:i], '.') {
+		if ttl, ok := c.ttls[method[:i]]; ok {
+			return ttl
+		}
+	}
+	return 0
+}
+
+// cacheKey derives the cache key of a call from its method and a hash of
+// its compacted params and of scope, the caller identity the result may
+// depend on.
+func cacheKey(method string, params json.RawMessage, scope []string) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, params) != nil {
+		buf.Reset()
+		buf.Write(params)
+	}
+	h := sha256.New()
+	for _, s := range scope {
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	h.Write(buf.Bytes())
+	sum := h.Sum(nil)
+	return method + "\x00" + hex.EncodeToString(sum[:])
+}
+
+// do returns the cached result for key, or calls fn and caches its result
+// for ttl when it succeeds. Callers missing while fn runs wait for it;
+// wait is called before they block.
+func (c *responseCache) do(ctx context.Context, key string, ttl time.Duration, wait func(), fn func() (json.RawMessage, error)) (json.RawMessage, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if time.Now().Before(e.expires) {
+			c.mu.Unlock()
+			return e.result, nil
+		}
+		delete(c.entries, key)
+	}
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+		wait()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded) {
+			// The call was abandoned by its caller, not failed by the backend.
+			return fn()
+		}
+		return f.result, f.err
+	}
+	f := &cacheFlight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	f.result, f.err = fn()
+	c.mu.Lock()
+	delete(c.flights, key)
+	if f.err == nil {
+		c.store(key, cacheEntry{result: f.result, expires: time.Now().Add(ttl)})
+	}
+	c.mu.Unlock()
+	close(f.done)
+	return f.result, f.err
+}
+
+// store caches e under key, evicting expired entries and then arbitrary
+// ones when the cache is full. c.mu must be held.
+func (c *responseCache) store(key string, e cacheEntry) {
+	if len(c.entries) >= c.size {
+		now := time.Now()
+		for k, old := range c.entries {
+			if !now.Before(old.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = e
+}
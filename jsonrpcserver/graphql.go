@@ -0,0 +1,604 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// GraphQLOpts configures the GraphQL facade served by Mux.GraphQLHandler.
+type GraphQLOpts struct {
+	// Mutations lists the methods exposed as mutation fields; every other
+	// registered method is a query field.
+	Mutations []string
+	// GetMethods lists the safe methods that may also be queried with GET;
+	// a GET query selecting any other field is rejected.
+	GetMethods []string
+	// FieldNames overrides the field names of methods, such as to resolve
+	// methods whose derived names collide.
+	FieldNames map[string]string
+	// MaxFields bounds the fields a query selects at every level, counting
+	// those of fragments; zero means 1000.
+	MaxFields int
+	// MaxDepth bounds the nesting of selection sets; zero means 16.
+	MaxDepth int
+}
+
+// Defaults of the GraphQLOpts limits.
+const (
+	defaultGraphQLMaxFields = 1000
+	defaultGraphQLMaxDepth  = 16
+)
+
+// GraphQLHandler serves the registered methods as the fields of a GraphQL
+// schema, resolving them by calling the handlers in-process, so clients
+// can consume the service through GraphQL. Each method becomes a field
+// named after it with dots replaced by underscores, unless
+// GraphQLOpts.FieldNames names it; methods whose names collide are left out
+// and reported as such. A field's arguments are the params object, or the
+// params as-is when the only argument is "params". Results are JSON:
+// selection sets pick fields from objects, and from the objects of lists,
+// but are not checked against a type.
+//
+// Requests are POSTed as application/json {"query", "operationName",
+// "variables"}, so that browsers cannot forge them from another site.
+// Queries selecting only GraphQLOpts.GetMethods may also be sent with GET.
+// Fragments, variables and the @include and @skip directives are
+// supported, introspection is not: GET without a query returns the schema
+// in SDL instead, as GraphQLSchema does.
+func (m *Mux) GraphQLHandler(opts GraphQLOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(correlate(w, r))
+		var req struct {
+			Query         string         `json:"query"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+			if req.Query == "" {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				_, _ = w.Write([]byte(m.GraphQLSchema(opts)))
+				return
+			}
+			if v := q.Get("variables"); v != "" {
+				if err := unmarshalNumbers([]byte(v), &req.Variables); err != nil {
+					writeGraphQL(w, http.StatusBadRequest, nil, []graphQLError{{Message: "invalid variables: " + err.Error()}})
+					return
+				}
+			}
+		case http.MethodPost:
+			if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+				writeGraphQL(w, http.StatusUnsupportedMediaType, nil, []graphQLError{{Message: "Content-Type must be application/json"}})
+				return
+			}
+			limit := m.maxRequestBytes
+			if limit <= 0 {
+				limit = defaultMaxBodyBytes
+			}
+			body := http.MaxBytesReader(w, r.Body, limit)
+			var buf bytes.Buffer
+			if _, err := buf.ReadFrom(body); err != nil {
+				writeGraphQL(w, http.StatusBadRequest, nil, []graphQLError{{Message: err.Error()}})
+				return
+			}
+			if err := unmarshalNumbers(buf.Bytes(), &req); err != nil {
+				writeGraphQL(w, http.StatusBadRequest, nil, []graphQLError{{Message: "invalid request body: " + err.Error()}})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeGraphQL(w, http.StatusMethodNotAllowed, nil, []graphQLError{{Message: "only GET and POST are supported"}})
+			return
+		}
+		doc, err := parseGraphQL(req.Query)
+		if err != nil {
+			writeGraphQL(w, http.StatusOK, nil, []graphQLError{{Message: err.Error()}})
+			return
+		}
+		op, err := doc.operation(req.OperationName)
+		if err != nil {
+			writeGraphQL(w, http.StatusOK, nil, []graphQLError{{Message: err.Error()}})
+			return
+		}
+		if op.kind == "mutation" && r.Method == http.MethodGet {
+			w.Header().Set("Allow", "POST")
+			writeGraphQL(w, http.StatusMethodNotAllowed, nil, []graphQLError{{Message: "mutations must be POSTed"}})
+			return
+		}
+		ex := &graphQLExec{
+			m:         m,
+			w:         w,
+			r:         r,
+			doc:       doc,
+			fields:    m.graphQLFields(opts),
+			vars:      make(map[string]any),
+			maxFields: opts.MaxFields,
+			maxDepth:  opts.MaxDepth,
+		}
+		if ex.maxFields <= 0 {
+			ex.maxFields = defaultGraphQLMaxFields
+		}
+		if ex.maxDepth <= 0 {
+			ex.maxDepth = defaultGraphQLMaxDepth
+		}
+		for _, v := range op.vars {
+			if val, ok := req.Variables[v.name]; ok {
+				ex.vars[v.name] = val
+			} else if v.def != nil {
+				ex.vars[v.name] = ex.resolve(v.def)
+			}
+		}
+		// Invalid documents and those over the limits run no field.
+		if count := 0; !ex.checkLimits(op.selections, 1, &count) || len(ex.errors) > 0 {
+			writeGraphQL(w, http.StatusOK, nil, ex.errors)
+			return
+		}
+		if r.Method == http.MethodGet {
+			if name, ok := ex.unsafeField(op, opts.GetMethods); ok {
+				w.Header().Set("Allow", "POST")
+				writeGraphQL(w, http.StatusMethodNotAllowed, nil, []graphQLError{{Message: fmt.Sprintf("field %q must be POSTed", name)}})
+				return
+			}
+		}
+		data := ex.run(op)
+		writeGraphQL(w, http.StatusOK, data, ex.errors)
+	})
+}
+
+// graphQLField is a method exposed as a field.
+type graphQLField struct {
+	method   string
+	mutation bool
+	// conflicts lists the methods sharing the field name, which then
+	// serves none of them.
+	conflicts []string
+}
+
+// graphQLFields maps field names to the registered methods.
+func (m *Mux) graphQLFields(opts GraphQLOpts) map[string]graphQLField {
+	handlers := m.allHandlers()
+	fields := make(map[string]graphQLField, len(handlers))
+	for _, method := range slices.Sorted(maps.Keys(handlers)) {
+		name, ok := opts.FieldNames[method]
+		if !ok {
+			name = graphQLName(method)
+		}
+		if f, ok := fields[name]; ok {
+			if f.conflicts == nil {
+				f.conflicts = []string{f.method}
+			}
+			fields[name] = graphQLField{conflicts: append(f.conflicts, method)}
+			continue
+		}
+		fields[name] = graphQLField{method: method, mutation: slices.Contains(opts.Mutations, method)}
+	}
+	return fields
+}
+
+// conflictError reports the methods colliding on the field name.
+func (f graphQLField) conflictError(name string) *Error {
+	msg := fmt.Sprintf("field %q is ambiguous: methods %s share its name; rename them with GraphQLOpts.FieldNames", name, strings.Join(f.conflicts, ", "))
+	return NewError(CodeMethodNotFound, msg, nil)
+}
+
+// graphQLName turns a method name into a valid GraphQL name.
+func graphQLName(method string) string {
+	b := []byte(method)
+	for i, c := range b {
+		if c != '_' && !isLetter(c) && (i == 0 || !isDigit(c)) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// operation returns the operation named name, or the only one.
+func (d *gqlDocument) operation(name string) (*gqlOperation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("operationName is required for documents with several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// graphQLError is an entry of the errors of a GraphQL response.
+type graphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// writeGraphQL writes a GraphQL response. data is left out when nil.
+func writeGraphQL(w http.ResponseWriter, status int, data *gqlObject, errs []graphQLError) {
+	resp := struct {
+		Data   *gqlObject     `json:"data,omitempty"`
+		Errors []graphQLError `json:"errors,omitempty"`
+	}{data, errs}
+	writeResponse(w, status, resp)
+}
+
+// gqlObject is a response object, keeping its fields in selection order.
+type gqlObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// set sets the field key, keeping the position of an existing one.
+func (o *gqlObject) set(key string, v any) {
+	if o.values == nil {
+		o.values = make(map[string]any)
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+// MarshalJSON implements json.Marshaler, writing the fields in order.
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		v, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// graphQLExec executes one operation.
+type graphQLExec struct {
+	m      *Mux
+	w      http.ResponseWriter
+	r      *http.Request
+	doc    *gqlDocument
+	fields map[string]graphQLField
+	vars   map[string]any
+	errors []graphQLError
+	// maxFields and maxDepth are the limits of GraphQLOpts.
+	maxFields int
+	maxDepth  int
+}
+
+// run resolves the root fields of op one after another.
+func (ex *graphQLExec) run(op *gqlOperation) *gqlObject {
+	typename := "Query"
+	if op.kind == "mutation" {
+		typename = "Mutation"
+	}
+	data := &gqlObject{}
+	for _, sel := range ex.collect(op.selections) {
+		if sel.name == "__typename" {
+			data.set(sel.alias, typename)
+			continue
+		}
+		f, ok := ex.fields[sel.name]
+		if ok && f.conflicts != nil {
+			ex.fail([]any{sel.alias}, f.conflictError(sel.name))
+			data.set(sel.alias, nil)
+			continue
+		}
+		if !ok || f.mutation != (op.kind == "mutation") {
+			ex.fail([]any{sel.alias}, NewError(CodeMethodNotFound, fmt.Sprintf("cannot query field %q on type %q", sel.name, typename), nil))
+			data.set(sel.alias, nil)
+			continue
+		}
+		data.set(sel.alias, ex.field(f.method, sel))
+	}
+	return data
+}
+
+// unsafeField returns the name of a root field of op that does not serve
+// one of the safe methods, if any.
+func (ex *graphQLExec) unsafeField(op *gqlOperation, safe []string) (string, bool) {
+	for _, sel := range ex.collect(op.selections) {
+		if sel.name == "__typename" {
+			continue
+		}
+		if f, ok := ex.fields[sel.name]; !ok || !slices.Contains(safe, f.method) {
+			return sel.name, true
+		}
+	}
+	return "", false
+}
+
+// field calls method with the arguments of sel and projects the result.
+func (ex *graphQLExec) field(method string, sel gqlSelection) any {
+	path := []any{sel.alias}
+	var params json.RawMessage
+	if len(sel.args) > 0 {
+		var v any
+		if len(sel.args) == 1 && sel.args[0].name == "params" {
+			v = ex.resolve(sel.args[0].value)
+		} else {
+			v = ex.resolve(sel.args)
+		}
+		var err error
+		if params, err = json.Marshal(v); err != nil {
+			ex.fail(path, WrapError(CodeInvalidParams, "invalid params", err))
+			return nil
+		}
+	}
+	resp := ex.m.serveCall(ex.w, ex.r, method, params)
+	if resp.Error != nil {
+		ex.fail(path, resp.Error)
+		return nil
+	}
+	result, err := json.Marshal(resp.Result)
+	var v any
+	if err == nil {
+		err = unmarshalNumbers(result, &v)
+	}
+	if err != nil {
+		ex.fail(path, NewError(CodeInternalError, "internal error", nil))
+		return nil
+	}
+	return ex.project(v, sel.selections)
+}
+
+// project picks the selected fields from v.
+func (ex *graphQLExec) project(v any, selections []gqlSelection) any {
+	if len(selections) == 0 {
+		return v
+	}
+	switch v := v.(type) {
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = ex.project(item, selections)
+		}
+		return out
+	case map[string]any:
+		out := &gqlObject{}
+		for _, sel := range ex.collect(selections) {
+			if sel.name == "__typename" {
+				out.set(sel.alias, "JSON")
+				continue
+			}
+			out.set(sel.alias, ex.project(v[sel.name], sel.selections))
+		}
+		return out
+	}
+	return v
+}
+
+// collect flattens fragments and applies @include and @skip, returning the
+// fields to resolve. Fields sharing a response key are merged into one,
+// their selections combined, and a fragment spread again in the same set
+// adds nothing, so a query cannot multiply the fields it resolves.
+func (ex *graphQLExec) collect(selections []gqlSelection) []gqlSelection {
+	c := &gqlCollector{ex: ex, index: make(map[string]int), spread: make(map[string]bool), visiting: make(map[string]bool)}
+	c.add(selections)
+	return c.out
+}
+
+// gqlCollector gathers the fields of one selection set.
+type gqlCollector struct {
+	ex    *graphQLExec
+	out   []gqlSelection
+	index map[string]int
+	// spread holds the fragments already expanded into the set, and
+	// visiting those being expanded, to detect cycles.
+	spread   map[string]bool
+	visiting map[string]bool
+}
+
+// add adds selections to the set.
+func (c *gqlCollector) add(selections []gqlSelection) {
+	for _, sel := range selections {
+		if !c.ex.included(sel.directives) {
+			continue
+		}
+		switch {
+		case sel.inline:
+			c.add(sel.selections)
+		case sel.spread != "":
+			frag, ok := c.ex.doc.fragments[sel.spread]
+			if !ok || c.visiting[sel.spread] {
+				c.ex.fail(nil, NewError(CodeInvalidRequest, fmt.Sprintf("unknown or cyclic fragment %q", sel.spread), nil))
+				continue
+			}
+			if c.spread[sel.spread] {
+				continue
+			}
+			c.spread[sel.spread] = true
+			c.visiting[sel.spread] = true
+			c.add(frag.selections)
+			delete(c.visiting, sel.spread)
+		default:
+			c.merge(sel)
+		}
+	}
+}
+
+// merge adds the field sel, merging it into an earlier field with the same
+// response key.
+func (c *gqlCollector) merge(sel gqlSelection) {
+	i, ok := c.index[sel.alias]
+	if !ok {
+		c.index[sel.alias] = len(c.out)
+		c.out = append(c.out, sel)
+		return
+	}
+	prev := &c.out[i]
+	if prev.name != sel.name || !reflect.DeepEqual(prev.args, sel.args) {
+		c.ex.fail(nil, NewError(CodeInvalidRequest, fmt.Sprintf("fields %q conflict: they differ in name or arguments", sel.alias), nil))
+		return
+	}
+	prev.selections = slices.Concat(prev.selections, sel.selections)
+}
+
+// checkLimits reports whether the fields selected by selections, at the
+// given depth, stay within the limits of ex, counting them into count. It
+// records an error when they do not.
+func (ex *graphQLExec) checkLimits(selections []gqlSelection, depth int, count *int) bool {
+	if depth > ex.maxDepth {
+		ex.fail(nil, NewError(CodeLimitExceeded, fmt.Sprintf("query exceeds the depth limit of %d", ex.maxDepth), nil))
+		return false
+	}
+	for _, sel := range ex.collect(selections) {
+		if *count++; *count > ex.maxFields {
+			ex.fail(nil, NewError(CodeLimitExceeded, fmt.Sprintf("query exceeds the limit of %d fields", ex.maxFields), nil))
+			return false
+		}
+		if len(sel.selections) > 0 && !ex.checkLimits(sel.selections, depth+1, count) {
+			return false
+		}
+	}
+	return true
+}
+
+// included evaluates the @include and @skip directives.
+func (ex *graphQLExec) included(directives []gqlArg) bool {
+	for _, d := range directives {
+		args, _ := d.value.([]gqlArg)
+		cond := false
+		for _, a := range args {
+			if a.name == "if" {
+				cond, _ = ex.resolve(a.value).(bool)
+			}
+		}
+		if d.name == "include" && !cond || d.name == "skip" && cond {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve substitutes variables into a parsed value.
+func (ex *graphQLExec) resolve(v any) any {
+	switch v := v.(type) {
+	case gqlVariable:
+		return ex.vars[string(v)]
+	case gqlEnum:
+		return string(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = ex.resolve(item)
+		}
+		return out
+	case []gqlArg:
+		out := &gqlObject{}
+		for _, a := range v {
+			out.set(a.name, ex.resolve(a.value))
+		}
+		return out
+	}
+	return v
+}
+
+// fail records a field error.
+func (ex *graphQLExec) fail(path []any, e error) {
+	var rpcErr *Error
+	if !errors.As(e, &rpcErr) {
+		rpcErr = NewError(CodeInternalError, e.Error(), nil)
+	}
+	ext := map[string]any{"code": rpcErr.Code}
+	if rpcErr.Data != nil {
+		ext["data"] = rpcErr.Data
+	}
+	ex.errors = append(ex.errors, graphQLError{Message: rpcErr.Message, Path: path, Extensions: ext})
+}
+
+// unmarshalNumbers decodes data into v, keeping numbers as json.Number.
+func unmarshalNumbers(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// GraphQLSchema describes the fields served by GraphQLHandler in SDL.
+// Results, and params whose type is not a scalar, are typed as the JSON
+// scalar. Colliding field names are listed in comments.
+func (m *Mux) GraphQLSchema(opts GraphQLOpts) string {
+	docs := make(map[string]OpenRPCMethod)
+	for _, d := range m.Discover().Methods {
+		docs[d.Name] = d
+	}
+	var queries, mutations, conflicts []string
+	fields := m.graphQLFields(opts)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		f := fields[name]
+		if f.conflicts != nil {
+			conflicts = append(conflicts, "# "+f.conflictError(name).Message)
+			continue
+		}
+		line := "  " + name + graphQLArgs(docs[f.method]) + ": JSON"
+		if f.mutation {
+			mutations = append(mutations, line)
+		} else {
+			queries = append(queries, line)
+		}
+	}
+	var b strings.Builder
+	for _, c := range conflicts {
+		b.WriteString(c + "\n")
+	}
+	b.WriteString("scalar JSON\n")
+	for _, t := range []struct {
+		name   string
+		fields []string
+	}{{"Query", queries}, {"Mutation", mutations}} {
+		if len(t.fields) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\ntype %s {\n%s\n}\n", t.name, strings.Join(t.fields, "\n"))
+	}
+	return b.String()
+}
+
+// graphQLArgs renders the arguments of a field from its method's params.
+func graphQLArgs(doc OpenRPCMethod) string {
+	if doc.ParamStructure != "by-name" {
+		return "(params: JSON)"
+	}
+	if len(doc.Params) == 0 {
+		return ""
+	}
+	args := make([]string, len(doc.Params))
+	for i, p := range doc.Params {
+		typ := "JSON"
+		switch p.Schema["type"] {
+		case "string":
+			typ = "String"
+		case "integer":
+			typ = "Int"
+		case "number":
+			typ = "Float"
+		case "boolean":
+			typ = "Boolean"
+		}
+		if p.Required {
+			typ += "!"
+		}
+		args[i] = graphQLName(p.Name) + ": " + typ
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
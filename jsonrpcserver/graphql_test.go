@@ -0,0 +1,98 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// postGraphQL posts query to the GraphQL handler of m and returns the
+// decoded response.
+func postGraphQL(t *testing.T, m *Mux, opts GraphQLOpts, query string) (data map[string]any, errs []graphQLError) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	r := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	m.GraphQLHandler(opts).ServeHTTP(w, r)
+	var resp struct {
+		Data   map[string]any `json:"data"`
+		Errors []graphQLError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return resp.Data, resp.Errors
+}
+
+func TestGraphQLFragmentsDoNotAmplify(t *testing.T) {
+	var calls atomic.Int64
+	m := NewMux()
+	m.Handle("item", HandlerFunc(func(context.Context, json.RawMessage) (any, error) {
+		calls.Add(1)
+		return map[string]any{"id": 1}, nil
+	}))
+
+	const levels = 18
+	var q strings.Builder
+	q.WriteString("{ ...F0 }\n")
+	for i := range levels {
+		fmt.Fprintf(&q, "fragment F%d on Query { ...F%d ...F%d }\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&q, "fragment F%d on Query { item { id } }\n", levels)
+
+	data, errs := postGraphQL(t, m, GraphQLOpts{}, q.String())
+	if len(errs) > 0 {
+		t.Fatalf("errors: %+v", errs)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler ran %d times, want 1", got)
+	}
+	if _, ok := data["item"]; !ok {
+		t.Errorf("data = %v, want item", data)
+	}
+}
+
+func TestGraphQLMergesFields(t *testing.T) {
+	m := NewMux()
+	m.Handle("user", HandlerFunc(func(context.Context, json.RawMessage) (any, error) {
+		return map[string]any{"id": 1, "name": "ann", "email": "a@x"}, nil
+	}))
+	data, errs := postGraphQL(t, m, GraphQLOpts{}, `{ user { id } user { name } }`)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %+v", errs)
+	}
+	user, _ := data["user"].(map[string]any)
+	if len(user) != 2 || user["id"] == nil || user["name"] == nil {
+		t.Errorf("user = %v, want id and name", user)
+	}
+
+	if _, errs := postGraphQL(t, m, GraphQLOpts{}, `{ u: user { id } u: __typename }`); len(errs) == 0 {
+		t.Error("conflicting fields were not reported")
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	var calls atomic.Int64
+	m := NewMux()
+	m.Handle("item", HandlerFunc(func(context.Context, json.RawMessage) (any, error) {
+		calls.Add(1)
+		return map[string]any{}, nil
+	}))
+	opts := GraphQLOpts{MaxFields: 3, MaxDepth: 2}
+	for _, q := range []string{
+		`{ a: item b: item c: item d: item }`,
+		`{ item { a { b } } }`,
+	} {
+		if _, errs := postGraphQL(t, m, opts, q); len(errs) == 0 || errs[0].Extensions["code"] != float64(CodeLimitExceeded) {
+			t.Errorf("%s: errors = %+v, want limit exceeded", q, errs)
+		}
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("handler ran %d times over the limits, want 0", got)
+	}
+}
@@ -0,0 +1,420 @@
+package jsonrpcserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// gqlDocument is a parsed GraphQL document.
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+// gqlOperation is a query or mutation.
+type gqlOperation struct {
+	kind       string
+	name       string
+	vars       []gqlVarDef
+	selections []gqlSelection
+}
+
+// gqlVarDef declares an operation variable and its default.
+type gqlVarDef struct {
+	name string
+	def  any
+}
+
+// gqlFragment is a named fragment. Type conditions are not checked, since
+// every result is plain JSON.
+type gqlFragment struct {
+	selections []gqlSelection
+}
+
+// gqlSelection is a field, a fragment spread or an inline fragment.
+type gqlSelection struct {
+	alias      string
+	name       string
+	args       []gqlArg
+	directives []gqlArg
+	selections []gqlSelection
+	// spread names the fragment of a fragment spread.
+	spread string
+	// inline marks an inline fragment.
+	inline bool
+}
+
+// gqlArg is a name and value pair: an argument, an object field, or a
+// directive with its arguments as value.
+type gqlArg struct {
+	name  string
+	value any
+}
+
+// gqlVariable is a reference to an operation variable.
+type gqlVariable string
+
+// gqlEnum is an enum value, resolved as its name.
+type gqlEnum string
+
+// gqlParser parses GraphQL documents by recursive descent.
+type gqlParser struct {
+	src string
+	pos int
+	tok string
+	// kind is one of "name", "int", "float", "string", "punct" or "eof".
+	kind string
+}
+
+// parseGraphQL parses an executable GraphQL document.
+func parseGraphQL(src string) (doc *gqlDocument, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(gqlSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, perr
+		}
+	}()
+	p := &gqlParser{src: src}
+	p.next()
+	doc = &gqlDocument{fragments: make(map[string]*gqlFragment)}
+	for p.kind != "eof" {
+		switch {
+		case p.is("{"):
+			doc.operations = append(doc.operations, &gqlOperation{kind: "query", selections: p.selectionSet()})
+		case p.kind == "name" && (p.tok == "query" || p.tok == "mutation"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.kind == "name" && p.tok == "fragment":
+			p.next()
+			name := p.name()
+			p.typeCondition()
+			p.directives()
+			doc.fragments[name] = &gqlFragment{selections: p.selectionSet()}
+		default:
+			p.fail("unexpected %q", p.tok)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, gqlSyntaxError("document has no operation")
+	}
+	return doc, nil
+}
+
+// gqlSyntaxError is a GraphQL parse error.
+type gqlSyntaxError string
+
+func (e gqlSyntaxError) Error() string { return string(e) }
+
+// fail aborts parsing with a syntax error at the current token.
+func (p *gqlParser) fail(format string, args ...any) {
+	panic(gqlSyntaxError(fmt.Sprintf("syntax error at offset %d: ", p.pos) + fmt.Sprintf(format, args...)))
+}
+
+// operation parses a query or mutation definition.
+func (p *gqlParser) operation() *gqlOperation {
+	op := &gqlOperation{kind: p.tok}
+	p.next()
+	if p.kind == "name" {
+		op.name = p.name()
+	}
+	if p.is("(") {
+		p.next()
+		for !p.is(")") {
+			p.expect("$")
+			v := gqlVarDef{name: p.name()}
+			p.expect(":")
+			p.typeRef()
+			if p.is("=") {
+				p.next()
+				v.def = p.value(true)
+			}
+			p.directives()
+			op.vars = append(op.vars, v)
+		}
+		p.next()
+	}
+	p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+// typeRef skips a type reference such as "[Int!]!".
+func (p *gqlParser) typeRef() {
+	if p.is("[") {
+		p.next()
+		p.typeRef()
+		p.expect("]")
+	} else {
+		p.name()
+	}
+	if p.is("!") {
+		p.next()
+	}
+}
+
+// typeCondition skips an "on Type" condition.
+func (p *gqlParser) typeCondition() {
+	if p.kind == "name" && p.tok == "on" {
+		p.next()
+		p.name()
+	}
+}
+
+// selectionSet parses "{ selection... }".
+func (p *gqlParser) selectionSet() []gqlSelection {
+	p.expect("{")
+	var out []gqlSelection
+	for !p.is("}") {
+		if p.kind == "eof" {
+			p.fail("unterminated selection set")
+		}
+		out = append(out, p.selection())
+	}
+	p.next()
+	return out
+}
+
+// selection parses a field or fragment.
+func (p *gqlParser) selection() gqlSelection {
+	if p.is("...") {
+		p.next()
+		if p.kind == "name" && p.tok != "on" {
+			s := gqlSelection{spread: p.name()}
+			s.directives = p.directives()
+			return s
+		}
+		p.typeCondition()
+		s := gqlSelection{inline: true, directives: p.directives()}
+		s.selections = p.selectionSet()
+		return s
+	}
+	s := gqlSelection{name: p.name()}
+	if p.is(":") {
+		p.next()
+		s.alias, s.name = s.name, p.name()
+	}
+	if p.is("(") {
+		s.args = p.arguments(false)
+	}
+	s.directives = p.directives()
+	if p.is("{") {
+		s.selections = p.selectionSet()
+	}
+	if s.alias == "" {
+		s.alias = s.name
+	}
+	return s
+}
+
+// arguments parses "(name: value, ...)".
+func (p *gqlParser) arguments(constant bool) []gqlArg {
+	p.expect("(")
+	var out []gqlArg
+	for !p.is(")") {
+		name := p.name()
+		p.expect(":")
+		out = append(out, gqlArg{name: name, value: p.value(constant)})
+	}
+	p.next()
+	return out
+}
+
+// directives parses "@name(args)..." with the arguments as value.
+func (p *gqlParser) directives() []gqlArg {
+	var out []gqlArg
+	for p.is("@") {
+		p.next()
+		d := gqlArg{name: p.name()}
+		if p.is("(") {
+			d.value = p.arguments(false)
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// value parses a literal or, unless constant, a variable.
+func (p *gqlParser) value(constant bool) any {
+	tok, kind := p.tok, p.kind
+	switch {
+	case tok == "$" && kind == "punct":
+		if constant {
+			p.fail("variable in constant value")
+		}
+		p.next()
+		return gqlVariable(p.name())
+	case tok == "[" && kind == "punct":
+		p.next()
+		list := []any{}
+		for !p.is("]") {
+			if p.kind == "eof" {
+				p.fail("unterminated list")
+			}
+			list = append(list, p.value(constant))
+		}
+		p.next()
+		return list
+	case tok == "{" && kind == "punct":
+		p.next()
+		var obj []gqlArg
+		for !p.is("}") {
+			name := p.name()
+			p.expect(":")
+			obj = append(obj, gqlArg{name: name, value: p.value(constant)})
+		}
+		p.next()
+		return obj
+	case kind == "int" || kind == "float":
+		p.next()
+		return json.Number(tok)
+	case kind == "string":
+		p.next()
+		return tok
+	case kind == "name":
+		p.next()
+		switch tok {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return gqlEnum(tok)
+	}
+	p.fail("unexpected %q", tok)
+	return nil
+}
+
+// is reports whether the current token is the punctuator tok.
+func (p *gqlParser) is(tok string) bool {
+	return p.kind == "punct" && p.tok == tok
+}
+
+// name consumes a name token.
+func (p *gqlParser) name() string {
+	if p.kind != "name" {
+		p.fail("expected name, found %q", p.tok)
+	}
+	tok := p.tok
+	p.next()
+	return tok
+}
+
+// expect consumes the punctuator tok.
+func (p *gqlParser) expect(tok string) {
+	if p.kind != "punct" || p.tok != tok {
+		p.fail("expected %q, found %q", tok, p.tok)
+	}
+	p.next()
+}
+
+// next advances to the following token, skipping whitespace, commas and
+// comments.
+func (p *gqlParser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.tok, p.kind = "", "eof"
+		return
+	}
+	start, c := p.pos, p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok, p.kind = "...", "punct"
+	case strings.ContainsRune("!$()&:=@[]{}|", rune(c)):
+		p.pos++
+		p.tok, p.kind = string(c), "punct"
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok, p.kind = p.src[start:p.pos], "name"
+	case c == '-' || isDigit(c):
+		p.number()
+	case c == '"':
+		p.string()
+	default:
+		p.fail("unexpected character %q", c)
+	}
+}
+
+// number lexes an int or float.
+func (p *gqlParser) number() {
+	start := p.pos
+	p.kind = "int"
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	digits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		p.kind = "float"
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		p.kind = "float"
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	p.tok = p.src[start:p.pos]
+	if !json.Valid([]byte(p.tok)) {
+		p.fail("invalid number %q", p.tok)
+	}
+}
+
+// string lexes a quoted or block string.
+func (p *gqlParser) string() {
+	p.kind = "string"
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			p.fail("unterminated block string")
+		}
+		p.tok = strings.TrimSpace(strings.ReplaceAll(p.src[p.pos+3:p.pos+3+end], `\"""`, `"""`))
+		p.pos += end + 6
+		return
+	}
+	end := p.pos + 1
+	for end < len(p.src) && p.src[end] != '"' && p.src[end] != '\n' {
+		if p.src[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(p.src) || p.src[end] != '"' {
+		p.fail("unterminated string")
+	}
+	// GraphQL string escapes are a subset of JSON's.
+	if err := json.Unmarshal([]byte(p.src[p.pos:end+1]), &p.tok); err != nil {
+		p.fail("invalid string: %v", err)
+	}
+	p.pos = end + 1
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
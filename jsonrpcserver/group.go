@@ -0,0 +1,90 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+// Router registers methods on a Mux with a shared prefix and middleware of
+// their own, wrapped inside the middleware of the Mux. It is created with
+// Mux.With, Mux.Group or Mux.Route.
+type Router struct {
+	mux        *Mux
+	prefix     string
+	middleware []Middleware
+}
+
+// With returns a Router whose methods are wrapped in mw.
+func (m *Mux) With(mw ...Middleware) *Router {
+	return &Router{mux: m, middleware: slices.Clone(mw)}
+}
+
+// Group calls fn with a Router whose middleware applies only to the methods
+// fn registers.
+func (m *Mux) Group(fn func(r *Router)) {
+	fn(&Router{mux: m})
+}
+
+// Route calls fn with a Router that registers its methods as
+// "prefix.method".
+func (m *Mux) Route(prefix string, fn func(r *Router)) {
+	fn(&Router{mux: m, prefix: prefix + "."})
+}
+
+// Use appends middleware for the methods registered through r afterwards.
+func (r *Router) Use(mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+}
+
+// With returns a copy of r with mw appended.
+func (r *Router) With(mw ...Middleware) *Router {
+	return &Router{mux: r.mux, prefix: r.prefix, middleware: append(slices.Clone(r.middleware), mw...)}
+}
+
+// Group calls fn with a copy of r whose added middleware applies only to
+// the methods fn registers.
+func (r *Router) Group(fn func(r *Router)) {
+	fn(r.With())
+}
+
+// Route calls fn with a copy of r that extends the prefix with prefix.
+func (r *Router) Route(prefix string, fn func(r *Router)) {
+	sub := r.With()
+	sub.prefix += prefix + "."
+	fn(sub)
+}
+
+// Handle registers h for the prefixed method, wrapped in r's middleware.
+func (r *Router) Handle(method string, h Handler) {
+	wrapped := h
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		wrapped = r.middleware[i](wrapped)
+	}
+	r.mux.Handle(r.prefix+method, groupHandler{Handler: wrapped, inner: h})
+}
+
+// HandleFunc registers fn for the prefixed method.
+func (r *Router) HandleFunc(method string, fn func(ctx context.Context, params json.RawMessage) (any, error)) {
+	r.Handle(method, HandlerFunc(fn))
+}
+
+// Mux returns the Mux r registers on.
+func (r *Router) Mux() *Mux {
+	return r.mux
+}
+
+// groupHandler is a handler wrapped in a Router's middleware. inner is the
+// handler as registered.
+type groupHandler struct {
+	Handler
+	inner Handler
+}
+
+// describe documents the registered handler.
+func (h groupHandler) describe(name string) OpenRPCMethod {
+	if d, ok := h.inner.(describer); ok {
+		return d.describe(name)
+	}
+	return OpenRPCMethod{Name: name, Params: []OpenRPCContentDescriptor{}}
+}
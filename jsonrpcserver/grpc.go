@@ -0,0 +1,325 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gRPC status codes used when mapping errors.
+const (
+	grpcOK                = 0
+	grpcCanceled          = 1
+	grpcUnknown           = 2
+	grpcInvalidArgument   = 3
+	grpcDeadlineExceeded  = 4
+	grpcNotFound          = 5
+	grpcPermissionDenied  = 7
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcInternal          = 13
+	grpcUnavailable       = 14
+	grpcUnauthenticated   = 16
+)
+
+// defaultGRPCMessageBytes bounds gRPC messages unless a limit is
+// configured, matching the default of gRPC implementations.
+const defaultGRPCMessageBytes = 4 << 20
+
+// grpcErrorTrailer carries the JSON-RPC error object alongside the gRPC
+// status, so a bridge on the other side can restore it exactly.
+const grpcErrorTrailer = "X-Jsonrpc-Error"
+
+// GRPCCodec transcodes gRPC messages between the protobuf wire format and
+// JSON. This package does not depend on protobuf and ships no codec:
+// implement one with protojson and the messages' descriptors to speak
+// binary "application/grpc". Without a codec only "application/grpc+json"
+// is spoken, whose messages are JSON as the peer's codec produces them.
+type GRPCCodec interface {
+	// Decode converts a protobuf message with the given full name to JSON.
+	Decode(message string, data []byte) (json.RawMessage, error)
+	// Encode converts JSON to a protobuf message with the given full name.
+	Encode(message string, data json.RawMessage) ([]byte, error)
+}
+
+// GRPCMethod describes a unary gRPC method.
+type GRPCMethod struct {
+	// Name is the method name within its service.
+	Name string
+	// Input and Output are the full names of the request and response
+	// messages, passed to the GRPCCodec.
+	Input  string
+	Output string
+}
+
+// GRPCOpts configures the gRPC service served by Mux.GRPCHandler.
+type GRPCOpts struct {
+	// Service is the full service name, such as "acme.users.v1.Users".
+	Service string
+	// Methods maps JSON-RPC methods to the gRPC methods exposing them; nil
+	// exposes every method under its own name.
+	Methods map[string]GRPCMethod
+	// Codec transcodes protobuf messages; see GRPCCodec.
+	Codec GRPCCodec
+}
+
+// GRPCHandler serves the registered methods as the unary methods of a gRPC
+// service, so gRPC clients can call them. Params and results are the JSON
+// form of the gRPC messages, transcoded by the GRPCCodec for protobuf
+// requests, and errors are mapped to gRPC status codes. Messages larger
+// than MuxOpts.MaxRequestBytes, or 4 MiB when it is unset, are rejected
+// with RESOURCE_EXHAUSTED. gRPC requires HTTP/2: serve it over TLS, or
+// enable unencrypted HTTP/2 on the http.Server. Streaming methods are not
+// supported.
+func (m *Mux) GRPCHandler(opts GRPCOpts) http.Handler {
+	byName := make(map[string]string, len(opts.Methods))
+	for rpc, gm := range opts.Methods {
+		byName[gm.Name] = rpc
+	}
+	prefix := "/" + opts.Service + "/"
+	limit := m.maxRequestBytes
+	if limit <= 0 {
+		limit = defaultGRPCMessageBytes
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(correlate(w, r))
+		contentType := r.Header.Get("Content-Type")
+		proto, ok := grpcContentType(contentType)
+		if r.Method != http.MethodPost || !ok {
+			http.Error(w, "gRPC requests must be POST with content-type application/grpc", http.StatusUnsupportedMediaType)
+			return
+		}
+		name, ok := strings.CutPrefix(r.URL.Path, prefix)
+		method, input, output := name, "", ""
+		if opts.Methods != nil {
+			method, ok = byName[name]
+			input, output = opts.Methods[method].Input, opts.Methods[method].Output
+		}
+		w.Header().Set("Content-Type", contentType)
+		if !ok {
+			writeGRPCStatus(w, NewError(CodeMethodNotFound, "method not found", r.URL.Path))
+			return
+		}
+		if proto && opts.Codec == nil {
+			writeGRPCStatus(w, NewError(CodeInvalidRequest, "invalid request", "protobuf messages need a GRPCCodec; use application/grpc+json"))
+			return
+		}
+		msg, err := readGRPCMessage(r.Body, limit)
+		if err != nil {
+			writeGRPCStatus(w, err)
+			return
+		}
+		params := json.RawMessage(msg)
+		if proto {
+			if params, err = opts.Codec.Decode(input, msg); err != nil {
+				writeGRPCStatus(w, WrapError(CodeInvalidParams, "invalid params", err))
+				return
+			}
+		}
+		ctx := r.Context()
+		if d, ok := parseGRPCTimeout(r.Header.Get("Grpc-Timeout")); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		resp := m.serveCall(w, r.WithContext(ctx), method, params)
+		if resp.Error != nil {
+			writeGRPCStatus(w, resp.Error)
+			return
+		}
+		out, err := json.Marshal(resp.Result)
+		if err == nil && proto {
+			out, err = opts.Codec.Encode(output, out)
+		}
+		if err != nil {
+			writeGRPCStatus(w, NewError(CodeInternalError, "internal error", nil))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		writeGRPCMessage(w, out)
+		writeGRPCStatus(w, nil)
+	})
+}
+
+// grpcContentType reports whether contentType is a gRPC content type and
+// whether its messages are protobuf rather than JSON.
+func grpcContentType(contentType string) (proto, ok bool) {
+	sub, ok := strings.CutPrefix(contentType, "application/grpc")
+	switch {
+	case !ok:
+		return false, false
+	case sub == "" || sub == "+proto":
+		return true, true
+	case sub == "+json":
+		return false, true
+	}
+	return false, false
+}
+
+// readGRPCMessage reads one length-prefixed gRPC message, rejecting
+// messages longer than limit before allocating them.
+func readGRPCMessage(r io.Reader, limit int64) ([]byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, WrapError(CodeInvalidRequest, "invalid request", err)
+	}
+	if hdr[0] != 0 {
+		return nil, NewError(CodeInvalidRequest, "invalid request", "compressed gRPC messages are not supported")
+	}
+	n := int64(binary.BigEndian.Uint32(hdr[1:]))
+	if n > limit {
+		return nil, NewError(CodeLimitExceeded, "message too large", fmt.Sprintf("gRPC message of %d bytes exceeds the limit of %d", n, limit))
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, WrapError(CodeInvalidRequest, "invalid request", err)
+	}
+	return msg, nil
+}
+
+// writeGRPCMessage writes msg as one uncompressed length-prefixed message.
+func writeGRPCMessage(w io.Writer, msg []byte) error {
+	var hdr [5]byte
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(msg)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// writeGRPCStatus ends a gRPC response with the status of err, written as
+// trailers.
+func writeGRPCStatus(w http.ResponseWriter, err error) {
+	h := w.Header()
+	if err == nil {
+		h.Set(http.TrailerPrefix+"Grpc-Status", "0")
+		return
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		e = NewError(CodeInternalError, "internal error", nil)
+	}
+	h.Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(grpcCode(e.Code)))
+	h.Set(http.TrailerPrefix+"Grpc-Message", encodeGRPCMessage(e.Message))
+	if data, err := json.Marshal(e); err == nil {
+		h.Set(http.TrailerPrefix+grpcErrorTrailer, encodeGRPCMessage(string(data)))
+	}
+}
+
+// grpcCode maps a JSON-RPC error code to a gRPC status code.
+func grpcCode(code int) int {
+	switch code {
+	case CodeParseError, CodeInvalidRequest, CodeInvalidParams:
+		return grpcInvalidArgument
+	case CodeMethodNotFound:
+		return grpcUnimplemented
+	case CodeInternalError:
+		return grpcInternal
+	case CodeTimeout:
+		return grpcDeadlineExceeded
+	case CodeCanceled:
+		return grpcCanceled
+	case CodeUnavailable, CodeBusy:
+		return grpcUnavailable
+	case CodeNotFound:
+		return grpcNotFound
+	case CodeLimitExceeded, CodeQuotaExceeded:
+		return grpcResourceExhausted
+	case CodeUnauthorized:
+		return grpcUnauthenticated
+	case CodeForbidden:
+		return grpcPermissionDenied
+	}
+	return grpcUnknown
+}
+
+// rpcCode maps a gRPC status code to a JSON-RPC error code.
+func rpcCode(code int) int {
+	switch code {
+	case grpcInvalidArgument:
+		return CodeInvalidParams
+	case grpcUnimplemented:
+		return CodeMethodNotFound
+	case grpcDeadlineExceeded:
+		return CodeTimeout
+	case grpcCanceled:
+		return CodeCanceled
+	case grpcUnavailable:
+		return CodeUnavailable
+	case grpcNotFound:
+		return CodeNotFound
+	case grpcResourceExhausted:
+		return CodeLimitExceeded
+	case grpcUnauthenticated:
+		return CodeUnauthorized
+	case grpcPermissionDenied:
+		return CodeForbidden
+	}
+	return CodeInternalError
+}
+
+// encodeGRPCMessage percent-encodes s as the gRPC spec requires for
+// grpc-message.
+func encodeGRPCMessage(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// decodeGRPCMessage reverses encodeGRPCMessage, keeping malformed escapes.
+func decodeGRPCMessage(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseGRPCTimeout parses a grpc-timeout header such as "250m".
+func parseGRPCTimeout(s string) (time.Duration, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	unit := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second, 'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond}[s[len(s)-1]]
+	if unit == 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// formatGRPCTimeout formats d as a grpc-timeout header.
+func formatGRPCTimeout(d time.Duration) string {
+	if ms := d.Milliseconds(); ms < 1e8 {
+		return strconv.FormatInt(max(ms, 1), 10) + "m"
+	}
+	return strconv.FormatInt(int64(d/time.Second), 10) + "S"
+}
@@ -0,0 +1,131 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GRPCBackend calls the unary methods of a gRPC service, so they can be
+// served as JSON-RPC methods:
+//
+//	m.Handle("users.get", backend.Handler(jsonrpcserver.GRPCMethod{Name: "GetUser"}))
+type GRPCBackend struct {
+	// Target is the base URL of the gRPC server, such as
+	// "https://users.internal:443".
+	Target string
+	// Service is the full service name, such as "acme.users.v1.Users".
+	Service string
+	// Client sends the calls and must speak HTTP/2; nil means
+	// http.DefaultClient, which does so over TLS.
+	Client *http.Client
+	// Codec transcodes protobuf messages; nil sends
+	// "application/grpc+json", which the server must accept.
+	Codec GRPCCodec
+	// Header is sent as metadata with every call.
+	Header http.Header
+	// MaxMessageBytes bounds the response messages; zero means 4 MiB.
+	MaxMessageBytes int64
+}
+
+// Handler returns a Handler calling method with the JSON-RPC params as its
+// request message and returning its response message as the result. gRPC
+// failures become JSON-RPC errors.
+func (b *GRPCBackend) Handler(method GRPCMethod) Handler {
+	return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+		return b.call(ctx, method, params)
+	})
+}
+
+// call invokes method with params.
+func (b *GRPCBackend) call(ctx context.Context, method GRPCMethod, params json.RawMessage) (json.RawMessage, error) {
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	msg, contentType := []byte(params), "application/grpc+json"
+	if b.Codec != nil {
+		var err error
+		if msg, err = b.Codec.Encode(method.Input, params); err != nil {
+			return nil, WrapError(CodeInvalidParams, "invalid params", err)
+		}
+		contentType = "application/grpc"
+	}
+	var body bytes.Buffer
+	writeGRPCMessage(&body, msg)
+	url := strings.TrimSuffix(b.Target, "/") + "/" + b.Service + "/" + method.Name
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range b.Header {
+		r.Header[name] = values
+	}
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("Te", "trailers")
+	if deadline, ok := ctx.Deadline(); ok {
+		r.Header.Set("Grpc-Timeout", formatGRPCTimeout(time.Until(deadline)))
+	}
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, NewError(CodeUnavailable, "upstream unavailable", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewError(CodeUnavailable, "upstream unavailable", "status "+resp.Status)
+	}
+	limit := b.MaxMessageBytes
+	if limit <= 0 {
+		limit = defaultGRPCMessageBytes
+	}
+	out, readErr := readGRPCMessage(resp.Body, limit)
+	// Trailers are only complete once the body is drained.
+	io.Copy(io.Discard, resp.Body)
+	if e := grpcStatusError(resp); e != nil {
+		return nil, e
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	if b.Codec != nil {
+		if out, err = b.Codec.Decode(method.Output, out); err != nil {
+			return nil, WrapError(CodeInternalError, "internal error", err)
+		}
+	}
+	return out, nil
+}
+
+// grpcStatusError returns the error carried by the grpc-status of resp,
+// read from its trailers or, for trailers-only responses, its headers.
+func grpcStatusError(resp *http.Response) *Error {
+	h := resp.Trailer
+	if h.Get("Grpc-Status") == "" {
+		h = resp.Header
+	}
+	status := h.Get("Grpc-Status")
+	code, err := strconv.Atoi(status)
+	if status == "" || err != nil {
+		return NewError(CodeInternalError, "internal error", "missing grpc-status")
+	}
+	if code == grpcOK {
+		return nil
+	}
+	if raw := h.Get(grpcErrorTrailer); raw != "" {
+		var e Error
+		if json.Unmarshal([]byte(decodeGRPCMessage(raw)), &e) == nil && e.Code != 0 {
+			return &e
+		}
+	}
+	return NewError(rpcCode(code), decodeGRPCMessage(h.Get("Grpc-Message")), map[string]any{"grpcStatus": code})
+}
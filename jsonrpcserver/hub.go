@@ -0,0 +1,95 @@
+package jsonrpcserver
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// defaultSendQueue is the per-session send queue length used when
+// MuxOpts.SendQueueSize is zero.
+const defaultSendQueue = 256
+
+// Hub tracks the WebSocket sessions of a Mux and pushes notifications to
+// them. Sessions belong to the Mux serving the connection, not to Muxes
+// mounted in it.
+type Hub struct {
+	mu       sync.RWMutex
+	sessions map[*Session]struct{}
+	counters hubCounters
+
+	// pubMu orders topic events and guards the replay buffer.
+	pubMu        sync.Mutex
+	seq          uint64
+	replay       []TopicEvent
+	replayWindow int
+	longPoll     *longPoll
+}
+
+// newHub creates an empty Hub.
+func newHub() *Hub {
+	return &Hub{sessions: make(map[*Session]struct{})}
+}
+
+// Hub returns the hub of m's WebSocket sessions.
+func (m *Mux) Hub() *Hub {
+	return m.hub
+}
+
+// add registers s.
+func (h *Hub) add(s *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessions[s] = struct{}{}
+}
+
+// remove unregisters s.
+func (h *Hub) remove(s *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, s)
+}
+
+// Sessions returns the connected sessions.
+func (h *Hub) Sessions() []*Session {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	out := make([]*Session, 0, len(h.sessions))
+	for s := range h.sessions {
+		out = append(out, s)
+	}
+	return out
+}
+
+// Len returns the number of connected sessions.
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.sessions)
+}
+
+// Broadcast pushes a notification to every session and returns how many
+// queued it. Sessions whose send queue is full are handled by the
+// backpressure policy.
+func (h *Hub) Broadcast(method string, params any) (int, error) {
+	return h.BroadcastWhere(nil, method, params)
+}
+
+// BroadcastWhere pushes a notification to the sessions for which filter
+// returns true, or to all of them when filter is nil, and returns how many
+// queued it.
+func (h *Hub) BroadcastWhere(filter func(*Session) bool, method string, params any) (int, error) {
+	data, err := json.Marshal(Notification{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, s := range h.Sessions() {
+		if filter != nil && !filter(s) {
+			continue
+		}
+		if s.trySend(data) {
+			sent++
+		}
+	}
+	return sent, nil
+}
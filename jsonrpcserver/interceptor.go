@@ -0,0 +1,37 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"my_rpc/jsonrpc"
+)
+
+// Intercept adapts client interceptors to server middleware, so the same
+// jsonrpc.Interceptor can be installed on both ends of a call. The first
+// interceptor is the outermost.
+func Intercept(ics ...jsonrpc.Interceptor) Middleware {
+	ic := jsonrpc.ChainInterceptors(ics...)
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			info := &jsonrpc.CallInfo{Side: jsonrpc.ServerSide, Header: http.Header{}}
+			if req := RequestFromContext(ctx); req != nil {
+				info.Method = req.Method
+			}
+			if r := HTTPRequestFromContext(ctx); r != nil {
+				info.Header = r.Header
+			}
+			return ic(jsonrpc.WithCallInfo(ctx, info), info, params, func(ctx context.Context, p any) (any, error) {
+				raw, ok := p.(json.RawMessage)
+				if !ok && p != nil {
+					var err error
+					if raw, err = json.Marshal(p); err != nil {
+						return nil, NewError(CodeInvalidParams, "Invalid params", err.Error())
+					}
+				}
+				return next.ServeRPC(ctx, raw)
+			})
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// IPRule allows or denies addresses for the methods of a namespace.
+type IPRule struct {
+	// Namespace selects the methods the rule applies to: "admin" matches
+	// "admin" and every "admin.*" method; "" matches all methods.
+	Namespace string
+	// Allow lists the CIDRs or addresses allowed; empty allows any address
+	// not denied.
+	Allow []string
+	// Deny lists the CIDRs or addresses rejected, taking precedence over
+	// Allow.
+	Deny []string
+}
+
+// IPFilterOpts configures the IPFilter middleware.
+type IPFilterOpts struct {
+	// Rules are all checked; a request must pass every rule whose
+	// namespace matches its method.
+	Rules []IPRule
+	// TrustedProxies lists the CIDRs of proxies whose X-Forwarded-For
+	// entries are believed. The client address is the rightmost entry not
+	// added by a trusted proxy.
+	TrustedProxies []string
+}
+
+// ipRule is an IPRule with parsed prefixes.
+type ipRule struct {
+	namespace   string
+	allow, deny []netip.Prefix
+}
+
+// IPFilter returns middleware that rejects requests from addresses the
+// rules do not allow with a CodeForbidden error. It fails when a CIDR does
+// not parse.
+func IPFilter(opts IPFilterOpts) (Middleware, error) {
+	trusted, err := parsePrefixes(opts.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]ipRule, len(opts.Rules))
+	for i, r := range opts.Rules {
+		rules[i].namespace = r.Namespace
+		if rules[i].allow, err = parsePrefixes(r.Allow); err != nil {
+			return nil, err
+		}
+		if rules[i].deny, err = parsePrefixes(r.Deny); err != nil {
+			return nil, err
+		}
+	}
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			req, hr := RequestFromContext(ctx), HTTPRequestFromContext(ctx)
+			if req == nil || hr == nil {
+				return next.ServeRPC(ctx, params)
+			}
+			addr, ok := ClientIP(hr, trusted)
+			for _, r := range rules {
+				if !inNamespace(req.Method, r.namespace) {
+					continue
+				}
+				if !ok || containsAddr(r.deny, addr) || len(r.allow) > 0 && !containsAddr(r.allow, addr) {
+					return nil, NewError(CodeForbidden, "forbidden", "address not allowed")
+				}
+			}
+			return next.ServeRPC(ctx, params)
+		})
+	}, nil
+}
+
+// ClientIP returns the address of the client of r: the remote address, or,
+// when it belongs to a trusted proxy, the rightmost X-Forwarded-For entry
+// not added by a trusted proxy.
+func ClientIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+	if !containsAddr(trusted, addr) {
+		return addr, true
+	}
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for _, hop := range slices.Backward(hops) {
+		a, err := netip.ParseAddr(strings.TrimSpace(hop))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		addr = a.Unmap()
+		if !containsAddr(trusted, addr) {
+			return addr, true
+		}
+	}
+	return addr, true
+}
+
+// inNamespace reports whether method belongs to namespace.
+func inNamespace(method, namespace string) bool {
+	return namespace == "" || method == namespace || strings.HasPrefix(method, namespace+".")
+}
+
+// containsAddr reports whether any prefix contains addr.
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	return slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// parsePrefixes parses CIDRs and bare addresses.
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		if p, err := netip.ParsePrefix(s); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address or CIDR %q", s)
+		}
+		out = append(out, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package jsonrpcserver
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+// ErrNoSystemdListeners is returned when the process was not started by
+// systemd socket activation.
+var ErrNoSystemdListeners = errors.New("no systemd socket-activation listeners")
+
+// ListenAndServeUnix listens on the Unix socket at path, replacing a stale
+// socket file, sets its permissions to perm and serves requests. The socket
+// file is removed when serving stops.
+func (s *Server) ListenAndServeUnix(path string, perm fs.FileMode) error {
+	l, err := ListenUnix(path, perm)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// ListenUnix listens on the Unix socket at path, replacing a stale socket
+// file, and sets its permissions to perm. Closing the listener removes the
+// socket file.
+func ListenUnix(path string, perm fs.FileMode) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// SystemdListeners returns the listeners passed by systemd socket
+// activation, keyed by their FileDescriptorName (or "LISTEN_FD_<n>" when
+// unnamed). It returns ErrNoSystemdListeners when there are none.
+func SystemdListeners() (map[string]net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, ErrNoSystemdListeners
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, ErrNoSystemdListeners
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make(map[string]net.Listener, n)
+	for i := range n {
+		fd := listenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" && names[i] != "unknown" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("systemd listener %s: %w", name, err)
+		}
+		listeners[name] = l
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	return listeners, nil
+}
+
+// ServeSystemd serves requests on every listener passed by systemd socket
+// activation and returns when all of them stop, with the first error other
+// than http.ErrServerClosed.
+func (s *Server) ServeSystemd() error {
+	listeners, err := SystemdListeners()
+	if err != nil {
+		return err
+	}
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func() { errs <- s.Serve(l) }()
+	}
+	var first error
+	for range listeners {
+		if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		return first
+	}
+	return http.ErrServerClosed
+}
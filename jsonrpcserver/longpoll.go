@@ -0,0 +1,223 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Names of the methods served by EnableLongPoll.
+const (
+	PollSubscribeMethod   = "poll.subscribe"
+	PollNextMethod        = "poll.next"
+	PollUnsubscribeMethod = "poll.unsubscribe"
+)
+
+// LongPollOpts configures long-polling.
+type LongPollOpts struct {
+	// MaxWait bounds how long poll.next holds a request open; it defaults to
+	// 30 seconds. Keep it below the HTTP write timeout.
+	MaxWait time.Duration
+	// QueueSize bounds the events buffered per subscription between polls;
+	// it defaults to 256 and the oldest events are dropped beyond it.
+	QueueSize int
+	// IdleTimeout expires subscriptions not polled for this long; it defaults
+	// to two minutes.
+	IdleTimeout time.Duration
+	// Authorize is consulted before a client subscribes to pattern.
+	Authorize func(ctx context.Context, pattern string) error
+}
+
+// PollResult is the result of poll.next.
+type PollResult struct {
+	Events []TopicEvent `json:"events"`
+	// Dropped counts events discarded because the queue overflowed since the
+	// previous poll.
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// pollSub is a topic subscription served by long-polling.
+type pollSub struct {
+	pattern string
+
+	mu      sync.Mutex
+	events  []TopicEvent
+	dropped int
+	polled  time.Time
+	ready   chan struct{}
+}
+
+// push buffers event and wakes a waiting poll.
+func (p *pollSub) push(event TopicEvent, limit int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.events) == limit {
+		p.events = p.events[1:]
+		p.dropped++
+	}
+	p.events = append(p.events, event)
+	select {
+	case p.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the buffered events, at most max of them when max is positive.
+func (p *pollSub) take(max int) PollResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.polled = time.Now()
+	n := len(p.events)
+	if max > 0 && n > max {
+		n = max
+	}
+	res := PollResult{Events: p.events[:n:n], Dropped: p.dropped}
+	p.events = append([]TopicEvent(nil), p.events[n:]...)
+	p.dropped = 0
+	if res.Events == nil {
+		res.Events = []TopicEvent{}
+	}
+	return res
+}
+
+// longPoll holds the long-poll subscriptions of a Hub.
+type longPoll struct {
+	opts LongPollOpts
+	mu   sync.Mutex
+	subs map[string]*pollSub
+}
+
+// deliver buffers event for the matching subscriptions and expires idle
+// ones.
+func (lp *longPoll) deliver(event TopicEvent) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	for id, p := range lp.subs {
+		p.mu.Lock()
+		idle := time.Since(p.polled) > lp.opts.IdleTimeout
+		p.mu.Unlock()
+		if idle {
+			delete(lp.subs, id)
+			continue
+		}
+		if matchTopic(p.pattern, event.Topic) {
+			p.push(event, lp.opts.QueueSize)
+		}
+	}
+}
+
+// get returns the subscription with id.
+func (lp *longPoll) get(id string) *pollSub {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.subs[id]
+}
+
+// EnableLongPoll serves topic subscriptions over plain HTTP for clients that
+// cannot hold a WebSocket open. poll.subscribe takes a pattern, like
+// topics.subscribe, and returns a subscription ID; poll.next holds the
+// request until events are published for it or the wait ends; and
+// poll.unsubscribe ends it. Events come from Hub.Publish and share its
+// sequence numbers and replay window.
+func (m *Mux) EnableLongPoll(opts LongPollOpts) {
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = 30 * time.Second
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultSendQueue
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 2 * time.Minute
+	}
+	lp := &longPoll{opts: opts, subs: make(map[string]*pollSub)}
+	m.hub.pubMu.Lock()
+	m.hub.longPoll = lp
+	m.hub.pubMu.Unlock()
+
+	Register(m, PollSubscribeMethod, func(ctx context.Context, args struct {
+		Pattern    string  `json:"pattern" validate:"required"`
+		ResumeFrom *uint64 `json:"resumeFrom"`
+	}) (string, error) {
+		if !validPattern(args.Pattern) {
+			return "", ErrInvalidParams("invalid topic pattern " + args.Pattern)
+		}
+		if opts.Authorize != nil {
+			if err := opts.Authorize(ctx, args.Pattern); err != nil {
+				return "", err
+			}
+		}
+		return m.hub.subscribePoll(args.Pattern, args.ResumeFrom), nil
+	})
+	Register(m, PollNextMethod, func(ctx context.Context, args struct {
+		Subscription string `json:"subscription" validate:"required"`
+		// WaitMs bounds the wait below MaxWait.
+		WaitMs int `json:"waitMs"`
+		Max    int `json:"max"`
+	}) (PollResult, error) {
+		p := lp.get(args.Subscription)
+		if p == nil {
+			return PollResult{}, NewError(CodeNotFound, "not found", "unknown subscription "+args.Subscription)
+		}
+		wait := opts.MaxWait
+		if args.WaitMs > 0 {
+			wait = min(wait, time.Duration(args.WaitMs)*time.Millisecond)
+		}
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		for {
+			if res := p.take(args.Max); len(res.Events) > 0 || res.Dropped > 0 {
+				return res, nil
+			}
+			select {
+			case <-p.ready:
+			case <-t.C:
+				return p.take(args.Max), nil
+			case <-ctx.Done():
+				return PollResult{}, ctx.Err()
+			}
+		}
+	})
+	Register(m, PollUnsubscribeMethod, func(ctx context.Context, args struct {
+		Subscription string `json:"subscription" validate:"required"`
+	}) (bool, error) {
+		lp.mu.Lock()
+		defer lp.mu.Unlock()
+		_, ok := lp.subs[args.Subscription]
+		delete(lp.subs, args.Subscription)
+		return ok, nil
+	})
+}
+
+// subscribePoll registers a long-poll subscription, buffering the replayed
+// events after resumeFrom.
+func (h *Hub) subscribePoll(pattern string, resumeFrom *uint64) string {
+	h.pubMu.Lock()
+	defer h.pubMu.Unlock()
+	lp := h.longPoll
+	p := &pollSub{pattern: pattern, polled: time.Now(), ready: make(chan struct{}, 1)}
+	if resumeFrom != nil {
+		for _, event := range h.replay {
+			if event.Seq > *resumeFrom && matchTopic(pattern, event.Topic) {
+				p.push(event, lp.opts.QueueSize)
+			}
+		}
+	}
+	id := newID()
+	lp.mu.Lock()
+	lp.subs[id] = p
+	lp.mu.Unlock()
+	return id
+}
+
+// pollSubscriptions returns the number of long-poll subscriptions of h.
+func (h *Hub) pollSubscriptions() int {
+	h.pubMu.Lock()
+	lp := h.longPoll
+	h.pubMu.Unlock()
+	if lp == nil {
+		return 0
+	}
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return len(lp.subs)
+}
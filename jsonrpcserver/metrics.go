@@ -0,0 +1,170 @@
+package jsonrpcserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histogram buckets.
+var DefaultLatencyBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// unknownMethod labels requests for methods that are not registered, so
+// that arbitrary method names do not create new series.
+const unknownMethod = "<unknown>"
+
+// Metrics collects request counts, error counts by code, per-method latency
+// histograms, the in-flight requests and the active sessions and
+// subscriptions of the Muxes it is attached to through MuxOpts.Metrics. It
+// renders them in the Prometheus text exposition format.
+type Metrics struct {
+	namespace string
+	buckets   []float64
+	inFlight  atomic.Int64
+
+	mu         sync.Mutex
+	methods    map[string]*methodMetrics
+	deprecated map[[2]string]uint64
+	hubs       []*Hub
+}
+
+// metricsKey is the context key under which the serving Mux's Metrics is
+// stored.
+type metricsKey struct{}
+
+// methodMetrics holds the series of one method.
+type methodMetrics struct {
+	requests uint64
+	errors   map[int]uint64
+	counts   []uint64
+	sum      float64
+}
+
+// NewMetrics creates a Metrics whose series names start with namespace,
+// e.g. "jsonrpc".
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{namespace: namespace, buckets: DefaultLatencyBuckets, methods: make(map[string]*methodMetrics), deprecated: make(map[[2]string]uint64)}
+}
+
+// attach registers the hub whose sessions and subscriptions are reported.
+func (mt *Metrics) attach(h *Hub) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.hubs = append(mt.hubs, h)
+}
+
+// begin counts a request as in flight and returns the function recording
+// its outcome.
+func (mt *Metrics) begin() func(method string, code int) {
+	mt.inFlight.Add(1)
+	start := time.Now()
+	return func(method string, code int) {
+		mt.inFlight.Add(-1)
+		mt.observe(method, code, time.Since(start))
+	}
+}
+
+// observe records one request outcome; code is zero on success.
+func (mt *Metrics) observe(method string, code int, d time.Duration) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mm, ok := mt.methods[method]
+	if !ok {
+		mm = &methodMetrics{errors: make(map[int]uint64), counts: make([]uint64, len(mt.buckets))}
+		mt.methods[method] = mm
+	}
+	mm.requests++
+	if code != 0 {
+		mm.errors[code]++
+	}
+	secs := d.Seconds()
+	mm.sum += secs
+	for i, b := range mt.buckets {
+		if secs <= b {
+			mm.counts[i]++
+		}
+	}
+}
+
+// observeDeprecated counts a call to a deprecated method version.
+func (mt *Metrics) observeDeprecated(method, version string) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.deprecated[[2]string{method, version}]++
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format.
+func (mt *Metrics) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	ns := mt.namespace
+	if ns != "" {
+		ns += "_"
+	}
+	mt.mu.Lock()
+	names := slices.Sorted(maps.Keys(mt.methods))
+	fmt.Fprintf(bw, "# HELP %srequests_total JSON-RPC requests served.\n# TYPE %srequests_total counter\n", ns, ns)
+	for _, name := range names {
+		fmt.Fprintf(bw, "%srequests_total{method=%s} %d\n", ns, quoteLabel(name), mt.methods[name].requests)
+	}
+	fmt.Fprintf(bw, "# HELP %serrors_total JSON-RPC error responses by code.\n# TYPE %serrors_total counter\n", ns, ns)
+	for _, name := range names {
+		mm := mt.methods[name]
+		for _, code := range slices.Sorted(maps.Keys(mm.errors)) {
+			fmt.Fprintf(bw, "%serrors_total{method=%s,code=\"%d\"} %d\n", ns, quoteLabel(name), code, mm.errors[code])
+		}
+	}
+	fmt.Fprintf(bw, "# HELP %srequest_duration_seconds JSON-RPC handler latency.\n# TYPE %srequest_duration_seconds histogram\n", ns, ns)
+	for _, name := range names {
+		mm, label := mt.methods[name], quoteLabel(name)
+		for i, b := range mt.buckets {
+			fmt.Fprintf(bw, "%srequest_duration_seconds_bucket{method=%s,le=\"%s\"} %d\n", ns, label, strconv.FormatFloat(b, 'g', -1, 64), mm.counts[i])
+		}
+		fmt.Fprintf(bw, "%srequest_duration_seconds_bucket{method=%s,le=\"+Inf\"} %d\n", ns, label, mm.requests)
+		fmt.Fprintf(bw, "%srequest_duration_seconds_sum{method=%s} %g\n", ns, label, mm.sum)
+		fmt.Fprintf(bw, "%srequest_duration_seconds_count{method=%s} %d\n", ns, label, mm.requests)
+	}
+	fmt.Fprintf(bw, "# HELP %sdeprecated_calls_total Calls to deprecated methods.\n# TYPE %sdeprecated_calls_total counter\n", ns, ns)
+	for _, key := range slices.SortedFunc(maps.Keys(mt.deprecated), func(a, b [2]string) int { return strings.Compare(a[0]+"\x00"+a[1], b[0]+"\x00"+b[1]) }) {
+		fmt.Fprintf(bw, "%sdeprecated_calls_total{method=%s,version=%s} %d\n", ns, quoteLabel(key[0]), quoteLabel(key[1]), mt.deprecated[key])
+	}
+	hubs := slices.Clone(mt.hubs)
+	mt.mu.Unlock()
+
+	sessions, subs := 0, 0
+	var dropped uint64
+	for _, h := range hubs {
+		for _, s := range h.Sessions() {
+			sessions++
+			subs += len(s.Subscriptions())
+		}
+		subs += h.pollSubscriptions()
+		dropped += h.Stats().Dropped
+	}
+	fmt.Fprintf(bw, "# HELP %sin_flight_requests JSON-RPC requests being served.\n# TYPE %sin_flight_requests gauge\n%sin_flight_requests %d\n", ns, ns, ns, mt.inFlight.Load())
+	fmt.Fprintf(bw, "# HELP %ssessions WebSocket sessions connected.\n# TYPE %ssessions gauge\n%ssessions %d\n", ns, ns, ns, sessions)
+	fmt.Fprintf(bw, "# HELP %sactive_subscriptions Active subscriptions.\n# TYPE %sactive_subscriptions gauge\n%sactive_subscriptions %d\n", ns, ns, ns, subs)
+	fmt.Fprintf(bw, "# HELP %snotifications_dropped_total Notifications dropped by backpressure.\n# TYPE %snotifications_dropped_total counter\n%snotifications_dropped_total %d\n", ns, ns, ns, dropped)
+	return bw.Flush()
+}
+
+// Handler serves the metrics for scraping, e.g. on /metrics.
+func (mt *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = mt.WritePrometheus(w)
+	})
+}
+
+// quoteLabel quotes a label value with the escapes of the text format.
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
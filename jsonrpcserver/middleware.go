@@ -0,0 +1,24 @@
+package jsonrpcserver
+
+// Middleware wraps a Handler with cross-cutting behavior such as recovery,
+// logging or authorization. The method being served is available through
+// RequestFromContext.
+type Middleware func(Handler) Handler
+
+// Use appends middleware to the chain wrapped around every handler. The
+// first middleware added is the outermost.
+func (m *Mux) Use(mw ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.middleware = append(m.middleware, mw...)
+}
+
+// chain wraps h with the registered middleware.
+func (m *Mux) chain(h Handler) Handler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := len(m.middleware) - 1; i >= 0; i-- {
+		h = m.middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,46 @@
+package jsonrpcserver
+
+import "strings"
+
+// Mount serves the methods of sub under prefix, so that "prefix.method"
+// reaches sub's "method". The middleware of sub wraps its own handlers,
+// inside the middleware of m. Prefixes may contain dots to mount deeper.
+func (m *Mux) Mount(prefix string, sub *Mux) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mounts[prefix] = sub
+}
+
+// Unmount removes the Mux mounted under prefix and reports whether there
+// was one.
+func (m *Mux) Unmount(prefix string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.mounts[prefix]
+	delete(m.mounts, prefix)
+	return ok
+}
+
+// mounted resolves method against the mounted Muxes, preferring the longest
+// matching prefix.
+func (m *Mux) mounted(method string) Handler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := strings.LastIndexByte(method, '.'); i > 0; i = strings.LastIndexByte(method[:i], '.') {
+		sub, ok := m.mounts[method[:i]]
+		if !ok {
+			continue
+		}
+		if h := sub.handler(method[i+1:]); h != nil {
+			return mountedHandler{Handler: sub.chain(h), inner: h}
+		}
+	}
+	return nil
+}
+
+// mountedHandler is a handler of a mounted Mux wrapped in that Mux's
+// middleware. inner is the handler as registered.
+type mountedHandler struct {
+	Handler
+	inner Handler
+}
@@ -0,0 +1,525 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Handler serves a single JSON-RPC method.
+type Handler interface {
+	ServeRPC(ctx context.Context, params json.RawMessage) (any, error)
+}
+
+// HandlerFunc adapts a function to the Handler interface.
+type HandlerFunc func(ctx context.Context, params json.RawMessage) (any, error)
+
+// ServeRPC calls f.
+func (f HandlerFunc) ServeRPC(ctx context.Context, params json.RawMessage) (any, error) {
+	return f(ctx, params)
+}
+
+// Request is a decoded JSON-RPC request.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// ID is a string, a json.Number or nil.
+	ID any `json:"id"`
+
+	// hasID records whether the id member was present.
+	hasID bool
+	// rawID is the id member as received.
+	rawID json.RawMessage
+}
+
+// UnmarshalJSON implements json.Unmarshaler, noting whether an id was sent.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	type plain Request
+	var aux struct {
+		plain
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*r = Request(aux.plain)
+	r.hasID, r.rawID = aux.ID != nil, aux.ID
+	if r.hasID {
+		dec := json.NewDecoder(bytes.NewReader(aux.ID))
+		dec.UseNumber()
+		return dec.Decode(&r.ID)
+	}
+	return nil
+}
+
+// IsNotification reports whether the request has no id and expects no response.
+func (r *Request) IsNotification() bool {
+	return !r.hasID
+}
+
+// Response is a JSON-RPC response.
+type Response struct {
+	// JSONRPC is "2.0" when MuxOpts.Strict is set and omitted otherwise, as
+	// the jsonrpc package client does not expect it.
+	JSONRPC string `json:"jsonrpc,omitempty"`
+	Result  any    `json:"result,omitempty"`
+	Error   *Error `json:"error,omitempty"`
+	ID      any    `json:"id"`
+}
+
+// MarshalJSON implements json.Marshaler. A successful response always
+// carries a result, which is null when the handler returned none.
+func (r Response) MarshalJSON() ([]byte, error) {
+	type response Response
+	if r.Error != nil || r.Result != nil {
+		return json.Marshal(response(r))
+	}
+	return json.Marshal(struct {
+		JSONRPC string `json:"jsonrpc,omitempty"`
+		Result  any    `json:"result"`
+		ID      any    `json:"id"`
+	}{r.JSONRPC, nil, r.ID})
+}
+
+// requestKey is the context key under which the current Request is stored.
+type requestKey struct{}
+
+// RequestFromContext returns the request being served, or nil.
+func RequestFromContext(ctx context.Context) *Request {
+	req, _ := ctx.Value(requestKey{}).(*Request)
+	return req
+}
+
+// httpRequestKey is the context key under which the HTTP request carrying
+// the JSON-RPC request is stored.
+type httpRequestKey struct{}
+
+// HTTPRequestFromContext returns the HTTP request carrying the JSON-RPC
+// request being served, or nil.
+func HTTPRequestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(httpRequestKey{}).(*http.Request)
+	return r
+}
+
+// IsNotification reports whether the request served under ctx is a
+// notification, whose result is discarded.
+func IsNotification(ctx context.Context) bool {
+	req := RequestFromContext(ctx)
+	return req != nil && req.IsNotification()
+}
+
+// Mux dispatches JSON-RPC requests to the handler registered for their
+// method. It implements http.Handler.
+type Mux struct {
+	mu                  sync.RWMutex
+	handlers            map[string]Handler
+	mounts              map[string]*Mux
+	middleware          []Middleware
+	batchConcurrency    int
+	errorMapper         ErrorMapper
+	info                OpenRPCInfo
+	timeout             time.Duration
+	methodTimeouts      map[string]time.Duration
+	maxRequestBytes     int64
+	maxBatchLength      int
+	checkOrigin         func(*http.Request) bool
+	sendQueueSize       int
+	backpressure        BackpressurePolicy
+	backpressureTimeout time.Duration
+	hub                 *Hub
+	admission           *admission
+	metrics             *Metrics
+	deprecated          map[string]Deprecation
+	callerHeaders       []string
+	strict              bool
+	detailedErrors      bool
+	devMode             bool
+	// gateway forwards methods no handler serves, as set by NewGateway.
+	gateway *Gateway
+}
+
+// MuxOpts contains options for creating a Mux.
+type MuxOpts struct {
+	// BatchConcurrency bounds the batch entries executed at once; zero or one
+	// runs them one after another. On a Gateway it bounds the sub-batches
+	// sent at once instead.
+	BatchConcurrency int
+	// ErrorMapper translates ordinary Go errors returned by handlers.
+	ErrorMapper ErrorMapper
+	// Info titles the OpenRPC document served by rpc.discover.
+	Info OpenRPCInfo
+	// Timeout bounds the execution of every handler; zero means no limit.
+	// Handlers still running when it passes get a canceled context and the
+	// client a CodeTimeout error.
+	Timeout time.Duration
+	// MethodTimeouts overrides Timeout for individual methods.
+	MethodTimeouts map[string]time.Duration
+	// MaxRequestBytes bounds the size of a request body; zero means no limit.
+	MaxRequestBytes int64
+	// MaxBatchLength bounds the number of members in a batch; zero means no
+	// limit.
+	MaxBatchLength int
+	// CheckOrigin decides whether a WebSocket upgrade from a browser origin
+	// is accepted; nil accepts only the endpoint's own host.
+	CheckOrigin func(r *http.Request) bool
+	// SendQueueSize is the number of outgoing messages buffered per WebSocket
+	// session; it defaults to 256.
+	SendQueueSize int
+	// Backpressure selects how notifications pushed to a session with a full
+	// send queue are handled; the default is DropNewest.
+	Backpressure BackpressurePolicy
+	// BackpressureTimeout bounds the wait of the Block policy; it defaults to
+	// one second.
+	BackpressureTimeout time.Duration
+	// Workers bounds the handlers executing at once; zero means no limit.
+	// Long-poll waits occupy a worker too, as do handlers still running
+	// after their timeout.
+	Workers int
+	// MaxQueue bounds the requests waiting for a worker; requests beyond it
+	// are shed with a CodeBusy error.
+	MaxQueue int
+	// Metrics, when set, records the requests served by the Mux.
+	Metrics *Metrics
+	// CallerHeaders selects the request headers exposed through
+	// CallerFromContext; nil exposes all but credentials.
+	CallerHeaders []string
+	// DetailedErrors sends ErrorDetails as the data of handler errors that
+	// carry none of their own.
+	DetailedErrors bool
+	// DevMode adds stack traces captured by WithStack or Recovery to the
+	// ErrorDetails. Keep it off in production.
+	DevMode bool
+	// Strict requires the jsonrpc member of requests to be "2.0" and adds it
+	// to responses. The jsonrpc package client neither sends nor accepts
+	// it, so leave Strict off when serving that client.
+	Strict bool
+}
+
+// NewMux creates an empty Mux with default options.
+func NewMux() *Mux {
+	return NewMuxWithOpts(nil)
+}
+
+// NewMuxWithOpts creates an empty Mux with custom options.
+func NewMuxWithOpts(opts *MuxOpts) *Mux {
+	m := &Mux{
+		handlers:       make(map[string]Handler),
+		mounts:         make(map[string]*Mux),
+		methodTimeouts: make(map[string]time.Duration),
+		hub:            newHub(),
+		deprecated:     make(map[string]Deprecation),
+		info:           OpenRPCInfo{Title: "JSON-RPC API", Version: "1.0.0"},
+	}
+	if opts == nil {
+		return m
+	}
+	if opts.Info != (OpenRPCInfo{}) {
+		m.info = opts.Info
+	}
+	m.batchConcurrency = opts.BatchConcurrency
+	m.errorMapper = opts.ErrorMapper
+	m.timeout = opts.Timeout
+	maps.Copy(m.methodTimeouts, opts.MethodTimeouts)
+	m.maxRequestBytes = opts.MaxRequestBytes
+	m.maxBatchLength = opts.MaxBatchLength
+	m.checkOrigin = opts.CheckOrigin
+	m.sendQueueSize = opts.SendQueueSize
+	m.backpressure = opts.Backpressure
+	m.backpressureTimeout = opts.BackpressureTimeout
+	m.admission = newAdmission(opts.Workers, opts.MaxQueue)
+	m.callerHeaders = opts.CallerHeaders
+	m.detailedErrors = opts.DetailedErrors
+	m.devMode = opts.DevMode
+	m.strict = opts.Strict
+	if m.metrics = opts.Metrics; m.metrics != nil {
+		m.metrics.attach(m.hub)
+	}
+	return m
+}
+
+// Handle registers h for method, replacing any previous handler. It is safe
+// to call while the Mux is serving.
+func (m *Mux) Handle(method string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[method] = h
+}
+
+// Unhandle removes the handler of method and reports whether there was one.
+// Like Handle it may be called while serving; requests already dispatched
+// to the handler run to completion.
+func (m *Mux) Unhandle(method string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.handlers[method]
+	delete(m.handlers, method)
+	return ok
+}
+
+// HandleFunc registers fn for method.
+func (m *Mux) HandleFunc(method string, fn func(ctx context.Context, params json.RawMessage) (any, error)) {
+	m.Handle(method, HandlerFunc(fn))
+}
+
+// handler returns the handler registered for method on m or on a mounted
+// Mux, or nil.
+func (m *Mux) handler(method string) Handler {
+	m.mu.RLock()
+	h, ok := m.handlers[method]
+	m.mu.RUnlock()
+	if ok {
+		return h
+	}
+	if h := m.mounted(method); h != nil {
+		return h
+	}
+	if h := m.builtin(method); h != nil {
+		return h
+	}
+	if m.gateway != nil {
+		return m.gateway.forwarder(method)
+	}
+	return nil
+}
+
+// ServeHTTP decodes a JSON-RPC request from r and writes the response,
+// echoing its correlation ID in the CorrelationHeader.
+// WebSocket upgrade requests are served as a session.
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWebSocketUpgrade(r) {
+		m.serveWebSocket(w, r)
+		return
+	}
+	r = r.WithContext(correlate(w, r))
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, &Response{Error: NewError(CodeInvalidRequest, "invalid request", "only POST method is supported")})
+		return
+	}
+	if m.maxRequestBytes > 0 {
+		if r.ContentLength > m.maxRequestBytes {
+			writeTooLarge(w, m.maxRequestBytes)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, m.maxRequestBytes)
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeTooLarge(w, m.maxRequestBytes)
+			return
+		}
+		writeResponse(w, http.StatusOK, m.stamp(&Response{Error: NewError(CodeParseError, "parse error", err.Error())}))
+		return
+	}
+	ctx := context.WithValue(r.Context(), httpRequestKey{}, r)
+	ctx = context.WithValue(ctx, deprecationKey{}, &deprecationNotes{})
+	ctx = m.withCaller(ctx, r, TransportHTTP)
+	if len(raw) > 0 && raw[0] == '[' {
+		m.serveBatch(w, ctx, raw)
+		return
+	}
+	resp, notify := m.serve(ctx, raw)
+	setDeprecationHeaders(ctx, w.Header())
+	if notify {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeResponse(w, http.StatusOK, m.stamp(resp))
+}
+
+// serveBatch executes the members of a batch and writes the responses of
+// those that are not notifications.
+func (m *Mux) serveBatch(w http.ResponseWriter, ctx context.Context, raw json.RawMessage) {
+	out := m.batch(ctx, raw)
+	setDeprecationHeaders(ctx, w.Header())
+	if out == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeResponse(w, http.StatusOK, m.stamp(out))
+}
+
+// batch executes the members of a batch and returns the responses of those
+// that are not notifications, a single error response for a malformed
+// batch, or nil when nothing must be sent.
+func (m *Mux) batch(ctx context.Context, raw json.RawMessage) any {
+	var members []json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return &Response{Error: NewError(CodeParseError, "parse error", err.Error())}
+	}
+	if len(members) == 0 {
+		return &Response{Error: NewError(CodeInvalidRequest, "invalid request", "empty batch")}
+	}
+	if m.maxBatchLength > 0 && len(members) > m.maxBatchLength {
+		msg := fmt.Sprintf("batch of %d requests exceeds the limit of %d", len(members), m.maxBatchLength)
+		return &Response{Error: NewError(CodeInvalidRequest, "invalid request", msg)}
+	}
+	resps := make([]*Response, len(members))
+	limit := max(m.batchConcurrency, 1)
+	var fan *fanOut
+	if m.gateway != nil {
+		// Members must all run to be grouped; the fanOut bounds the
+		// sub-batches instead.
+		fan = m.gateway.newFanOut(ctx, len(members))
+		limit = len(members)
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, member := range members {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			ctx := ctx
+			if fan != nil {
+				var leave func()
+				ctx, leave = fan.enter(ctx)
+				defer leave()
+			}
+			if resp, notify := m.serve(ctx, member); !notify {
+				resps[i] = resp
+			}
+		}()
+	}
+	wg.Wait()
+	out := make([]*Response, 0, len(resps))
+	for _, resp := range resps {
+		if resp != nil {
+			out = append(out, resp)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// serveCall serves method with params as a call arriving over r, for the
+// handlers that translate other protocols into calls.
+func (m *Mux) serveCall(w http.ResponseWriter, r *http.Request, method string, params json.RawMessage) *Response {
+	raw, err := json.Marshal(Request{Method: method, Params: params, ID: json.Number("1")})
+	if err != nil {
+		return &Response{Error: WrapError(CodeInvalidParams, "invalid params", err)}
+	}
+	ctx := context.WithValue(r.Context(), httpRequestKey{}, r)
+	ctx = context.WithValue(ctx, deprecationKey{}, &deprecationNotes{})
+	ctx = m.withCaller(ctx, r, TransportHTTP)
+	resp, _ := m.serve(ctx, raw)
+	setDeprecationHeaders(ctx, w.Header())
+	return resp
+}
+
+// serve decodes and dispatches one request. It also reports whether the
+// request was a notification, whose response must not be sent.
+func (m *Mux) serve(ctx context.Context, raw json.RawMessage) (resp *Response, notify bool) {
+	if len(raw) == 0 || raw[0] != '{' {
+		return &Response{Error: NewError(CodeInvalidRequest, "invalid request", "request must be an object")}, false
+	}
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return &Response{Error: NewError(CodeInvalidRequest, "invalid request", err.Error())}, false
+	}
+	if e := m.validateRequest(&req); e != nil {
+		return &Response{Error: e, ID: validID(&req)}, false
+	}
+	notify = req.IsNotification()
+	if CorrelationID(ctx) == "" {
+		ctx = withCorrelationID(ctx, newID())
+	}
+	defer func() {
+		if resp != nil && resp.Error != nil {
+			resp.Error = withCorrelationData(resp.Error, CorrelationID(ctx))
+		}
+	}()
+	h := m.handler(req.Method)
+	if m.metrics != nil {
+		ctx = context.WithValue(ctx, metricsKey{}, m.metrics)
+		done := m.metrics.begin()
+		method := req.Method
+		if h == nil {
+			method = unknownMethod
+		}
+		defer func() {
+			code := 0
+			if resp != nil && resp.Error != nil {
+				code = resp.Error.Code
+			}
+			done(method, code)
+		}()
+	}
+	if h == nil {
+		return &Response{Error: NewError(CodeMethodNotFound, "method not found", req.Method), ID: req.responseID()}, notify
+	}
+	ctx = context.WithValue(ctx, requestKey{}, &req)
+	if scopes := scopesOf(ctx, h, req.Params); scopes != nil {
+		ctx = context.WithValue(ctx, scopesKey{}, scopes)
+	}
+	m.mu.RLock()
+	d, deprecated := m.deprecated[req.Method]
+	m.mu.RUnlock()
+	if deprecated {
+		noteDeprecation(ctx, req.Method, "", d)
+	}
+	h = m.chain(h)
+	if m.admission != nil {
+		if err := m.admission.acquire(ctx); err != nil {
+			return &Response{Error: m.mapError(err), ID: req.responseID()}, notify
+		}
+		// The slot is held until the handler returns, even past a timeout.
+		admitted := h
+		h = HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			defer m.admission.release()
+			return admitted.ServeRPC(ctx, params)
+		})
+	}
+	if d := m.timeoutFor(req.Method); d > 0 {
+		h = withTimeout(h, d)
+	}
+	result, err := h.ServeRPC(ctx, req.Params)
+	if err != nil {
+		return &Response{Error: m.mapError(err), ID: req.responseID()}, notify
+	}
+	return &Response{Result: result, ID: req.responseID()}, notify
+}
+
+// writeTooLarge rejects a request body larger than limit bytes.
+func writeTooLarge(w http.ResponseWriter, limit int64) {
+	msg := fmt.Sprintf("request body exceeds the limit of %d bytes", limit)
+	writeResponse(w, http.StatusRequestEntityTooLarge, &Response{Error: NewError(CodeInvalidRequest, "invalid request", msg)})
+}
+
+// defaultMaxBodyBytes bounds the bodies read by wrappers that inspect a
+// request before the Mux does, unless they are configured otherwise.
+const defaultMaxBodyBytes = 1 << 20
+
+// peekBody reads the body of r, failing with *http.MaxBytesError past limit
+// bytes or defaultMaxBodyBytes when limit is not positive, and restores it
+// for the next reader.
+func peekBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = defaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// writeResponse encodes resp as the JSON body of an HTTP response.
+func writeResponse(w http.ResponseWriter, status int, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,39 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoNotifier is returned by Notify for requests that did not arrive over
+// a connection-oriented transport.
+var ErrNoNotifier = errors.New("rpc request has no connection to notify")
+
+// Notifier pushes notifications to the client that issued a request.
+// Notifications sent while the handler runs reach the client before its
+// response; the Notifier stays usable after the handler returns, until the
+// connection closes.
+type Notifier interface {
+	Notify(method string, params any) error
+}
+
+// NotifierFromContext returns the Notifier of the connection the request
+// arrived on. It reports false for plain HTTP requests, which have no way
+// to carry notifications.
+func NotifierFromContext(ctx context.Context) (Notifier, bool) {
+	s := SessionFromContext(ctx)
+	if s == nil {
+		return nil, false
+	}
+	return s, true
+}
+
+// Notify pushes a notification to the client that issued the request served
+// under ctx.
+func Notify(ctx context.Context, method string, params any) error {
+	n, ok := NotifierFromContext(ctx)
+	if !ok {
+		return ErrNoNotifier
+	}
+	return n.Notify(method, params)
+}
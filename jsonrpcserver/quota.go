@@ -0,0 +1,180 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// QuotaPeriod is the budget period of a quota. Periods start at midnight
+// UTC.
+type QuotaPeriod int
+
+const (
+	// Daily budgets reset every day.
+	Daily QuotaPeriod = iota
+	// Monthly budgets reset on the first of every month.
+	Monthly
+)
+
+// String returns "daily" or "monthly".
+func (p QuotaPeriod) String() string {
+	if p == Monthly {
+		return "monthly"
+	}
+	return "daily"
+}
+
+// window returns the start and end of the period containing t.
+func (p QuotaPeriod) window(t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	if p == Monthly {
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Quota is a call budget per period.
+type Quota struct {
+	Limit  int64
+	Period QuotaPeriod
+}
+
+// QuotaStore counts calls against budgets. Consume adds one call to the
+// counter named key, which expires at reset, and returns the new count.
+type QuotaStore interface {
+	Consume(ctx context.Context, key string, reset time.Time) (int64, error)
+}
+
+// QuotaOpts configures the Quotas middleware.
+type QuotaOpts struct {
+	Store QuotaStore
+	// Key identifies the budget holder, e.g. KeyByHeader("X-API-Key").
+	// Requests with an empty key are not metered.
+	Key RateKeyFunc
+	// Class maps a method to its class; nil puts every method in "default".
+	Class func(method string) string
+	// Quotas holds the budgets of each class; classes without one are not
+	// metered. Several quotas per class, e.g. daily and monthly, all apply.
+	Quotas map[string][]Quota
+}
+
+// QuotaExceededData is the data of a CodeQuotaExceeded error.
+type QuotaExceededData struct {
+	Class             string    `json:"class"`
+	Period            string    `json:"period"`
+	Limit             int64     `json:"limit"`
+	ResetAt           time.Time `json:"resetAt"`
+	ResetAfterSeconds float64   `json:"resetAfterSeconds"`
+}
+
+// Quotas returns middleware that meters calls per key and method class
+// against daily or monthly budgets and answers calls over budget with a
+// CodeQuotaExceeded error whose data carries a QuotaExceededData.
+func Quotas(opts QuotaOpts) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			req := RequestFromContext(ctx)
+			key := opts.Key(ctx)
+			if req == nil || key == "" {
+				return next.ServeRPC(ctx, params)
+			}
+			class := "default"
+			if opts.Class != nil {
+				class = opts.Class(req.Method)
+			}
+			now := time.Now()
+			for _, q := range opts.Quotas[class] {
+				start, reset := q.Period.window(now)
+				counter := fmt.Sprintf("%s:%s:%s:%d", key, class, q.Period, start.Unix())
+				used, err := opts.Store.Consume(ctx, counter, reset)
+				if err != nil {
+					return nil, fmt.Errorf("quota store: %w", err)
+				}
+				if used > q.Limit {
+					return nil, NewError(CodeQuotaExceeded, "quota exceeded", QuotaExceededData{
+						Class:             class,
+						Period:            q.Period.String(),
+						Limit:             q.Limit,
+						ResetAt:           reset,
+						ResetAfterSeconds: math.Ceil(reset.Sub(now).Seconds()),
+					})
+				}
+			}
+			return next.ServeRPC(ctx, params)
+		})
+	}
+}
+
+// MemoryQuotaStore is an in-process QuotaStore for a single server.
+type MemoryQuotaStore struct {
+	mu       sync.Mutex
+	counters map[string]*quotaCounter
+}
+
+// quotaCounter is the count of one budget period.
+type quotaCounter struct {
+	count int64
+	reset time.Time
+}
+
+// NewMemoryQuotaStore creates an empty MemoryQuotaStore.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counters: make(map[string]*quotaCounter)}
+}
+
+// Consume implements QuotaStore. Expired counters are dropped as new ones
+// are created.
+func (s *MemoryQuotaStore) Consume(_ context.Context, key string, reset time.Time) (int64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counters[key]
+	if !ok {
+		for k, old := range s.counters {
+			if !now.Before(old.reset) {
+				delete(s.counters, k)
+			}
+		}
+		c = &quotaCounter{reset: reset}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+// redisConsumeScript increments a counter and sets its expiry on creation.
+const redisConsumeScript = `
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then redis.call("PEXPIREAT", KEYS[1], ARGV[1]) end
+return n`
+
+// RedisQuotaStore is a QuotaStore shared by every server using the same
+// Redis.
+type RedisQuotaStore struct {
+	Client RedisScripter
+	// Prefix is prepended to every key; it defaults to "jsonrpc:quota:".
+	Prefix string
+}
+
+// Consume implements QuotaStore.
+func (s *RedisQuotaStore) Consume(ctx context.Context, key string, reset time.Time) (int64, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "jsonrpc:quota:"
+	}
+	res, err := s.Client.Eval(ctx, redisConsumeScript, []string{prefix + key}, reset.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	n, ok := res.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected redis reply %v", res)
+	}
+	return n, nil
+}
@@ -0,0 +1,213 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// Rate is the number of requests allowed per window.
+type Rate struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RateDecision is the outcome of counting a request against a Rate.
+type RateDecision struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is the time until the current window resets.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore counts requests per key in fixed windows.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rate Rate) (RateDecision, error)
+}
+
+// RateKeyFunc extracts the key a request is limited under. An empty key
+// exempts the request from limiting.
+type RateKeyFunc func(ctx context.Context) string
+
+// KeyByIP limits per client IP address, taken from the HTTP remote address.
+func KeyByIP(ctx context.Context) string {
+	r := HTTPRequestFromContext(ctx)
+	if r == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// KeyByMethod limits per JSON-RPC method across all clients.
+func KeyByMethod(ctx context.Context) string {
+	if req := RequestFromContext(ctx); req != nil {
+		return req.Method
+	}
+	return ""
+}
+
+// KeyByHeader limits per value of an HTTP header, such as an API key.
+// Requests without the header are not limited.
+func KeyByHeader(name string) RateKeyFunc {
+	return func(ctx context.Context) string {
+		if r := HTTPRequestFromContext(ctx); r != nil {
+			return r.Header.Get(name)
+		}
+		return ""
+	}
+}
+
+// RateLimitData is the data of a CodeLimitExceeded error.
+type RateLimitData struct {
+	Limit        int     `json:"limit"`
+	WindowMs     int64   `json:"windowMs"`
+	RetryAfter   float64 `json:"retryAfter"`
+	RetryAfterMs int64   `json:"retryAfterMs"`
+}
+
+// RateLimit returns middleware that allows rate.Requests per rate.Window for
+// each key returned by key and answers requests over the limit with a
+// CodeLimitExceeded error whose data carries a RateLimitData retry hint.
+// Store errors are returned to the client as internal errors.
+func RateLimit(store RateLimitStore, rate Rate, key RateKeyFunc) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			k := key(ctx)
+			if k == "" {
+				return next.ServeRPC(ctx, params)
+			}
+			d, err := store.Take(ctx, k, rate)
+			if err != nil {
+				return nil, fmt.Errorf("rate limit store: %w", err)
+			}
+			if !d.Allowed {
+				return nil, NewError(CodeLimitExceeded, "rate limited", RateLimitData{
+					Limit:        rate.Requests,
+					WindowMs:     rate.Window.Milliseconds(),
+					RetryAfter:   math.Ceil(d.RetryAfter.Seconds()),
+					RetryAfterMs: d.RetryAfter.Milliseconds(),
+				})
+			}
+			return next.ServeRPC(ctx, params)
+		})
+	}
+}
+
+// MemoryRateStore is an in-process RateLimitStore for a single server.
+type MemoryRateStore struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	swept   time.Time
+}
+
+// rateWindow counts the requests of one key in its current window.
+type rateWindow struct {
+	count int
+	reset time.Time
+}
+
+// NewMemoryRateStore creates an empty MemoryRateStore.
+func NewMemoryRateStore() *MemoryRateStore {
+	return &MemoryRateStore{windows: make(map[string]*rateWindow)}
+}
+
+// Take counts a request for key and reports whether it is within rate.
+func (s *MemoryRateStore) Take(_ context.Context, key string, rate Rate) (RateDecision, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now, rate.Window)
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &rateWindow{reset: now.Add(rate.Window)}
+		s.windows[key] = w
+	}
+	w.count++
+	return RateDecision{
+		Allowed:    w.count <= rate.Requests,
+		Remaining:  max(rate.Requests-w.count, 0),
+		RetryAfter: w.reset.Sub(now),
+	}, nil
+}
+
+// sweep drops expired windows at most once per window length.
+func (s *MemoryRateStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.swept) < window {
+		return
+	}
+	s.swept = now
+	for k, w := range s.windows {
+		if !now.Before(w.reset) {
+			delete(s.windows, k)
+		}
+	}
+}
+
+// RedisScripter runs a Lua script on Redis. Adapt the client of your choice,
+// e.g. for go-redis:
+//
+//	func(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		return rdb.Eval(ctx, script, keys, args...).Result()
+//	}
+type RedisScripter interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// RedisScripterFunc adapts a function to the RedisScripter interface.
+type RedisScripterFunc func(ctx context.Context, script string, keys []string, args ...any) (any, error)
+
+// Eval calls f.
+func (f RedisScripterFunc) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// redisTakeScript increments the window counter, starting its expiry on the
+// first request, and returns the count and the milliseconds left.
+const redisTakeScript = `
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then redis.call("PEXPIRE", KEYS[1], ARGV[1]) end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then redis.call("PEXPIRE", KEYS[1], ARGV[1]); ttl = tonumber(ARGV[1]) end
+return {n, ttl}`
+
+// RedisRateStore is a RateLimitStore shared by every server using the same
+// Redis, counting in fixed windows with INCR and PEXPIRE.
+type RedisRateStore struct {
+	Client RedisScripter
+	// Prefix is prepended to every key; it defaults to "jsonrpc:ratelimit:".
+	Prefix string
+}
+
+// Take counts a request for key and reports whether it is within rate.
+func (s *RedisRateStore) Take(ctx context.Context, key string, rate Rate) (RateDecision, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "jsonrpc:ratelimit:"
+	}
+	res, err := s.Client.Eval(ctx, redisTakeScript, []string{prefix + key}, rate.Window.Milliseconds())
+	if err != nil {
+		return RateDecision{}, err
+	}
+	vals, ok := res.([]any)
+	if !ok || len(vals) != 2 {
+		return RateDecision{}, fmt.Errorf("unexpected redis reply %v", res)
+	}
+	count, ok1 := vals[0].(int64)
+	ttl, ok2 := vals[1].(int64)
+	if !ok1 || !ok2 {
+		return RateDecision{}, fmt.Errorf("unexpected redis reply %v", res)
+	}
+	return RateDecision{
+		Allowed:    int(count) <= rate.Requests,
+		Remaining:  max(rate.Requests-int(count), 0),
+		RetryAfter: time.Duration(ttl) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,38 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// Recovery returns middleware that turns a handler panic into an internal
+// error response instead of crashing the server. Panics are logged to logger
+// when it is non-nil, with the stack trace when captureStack is set.
+func Recovery(logger *slog.Logger, captureStack bool) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (result any, err error) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				result, err = nil, &Error{Code: CodeInternalError, Message: "internal error", cause: WithStack(fmt.Errorf("panic: %v", p))}
+				if logger == nil {
+					return
+				}
+				attrs := []any{"panic", fmt.Sprint(p), "correlation_id", CorrelationID(ctx)}
+				if req := RequestFromContext(ctx); req != nil {
+					attrs = append(attrs, "method", req.Method)
+				}
+				if captureStack {
+					attrs = append(attrs, "stack", string(debug.Stack()))
+				}
+				logger.ErrorContext(ctx, "rpc handler panicked", attrs...)
+			}()
+			return next.ServeRPC(ctx, params)
+		})
+	}
+}
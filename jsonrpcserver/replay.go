@@ -0,0 +1,182 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NonceStore remembers nonces for the freshness window. Add records nonce
+// until ttl passes and reports false when it was already recorded.
+type NonceStore interface {
+	Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// ReplayOpts configures the RejectReplays wrapper.
+type ReplayOpts struct {
+	Store NonceStore
+	// Header names default to "X-Nonce" and "X-Timestamp"; the timestamp
+	// is in Unix seconds.
+	NonceHeader     string
+	TimestampHeader string
+	// Window bounds the age of the timestamp and how long nonces are
+	// remembered; it defaults to five minutes.
+	Window time.Duration
+	// Methods lists the protected methods; requests for other methods pass
+	// unchecked. Empty protects every request, and so do bodies that do
+	// not parse as a call or batch.
+	Methods []string
+	// MaxBodyBytes bounds the body read to find the methods; zero means
+	// 1 MiB. Larger bodies are answered with HTTP 413.
+	MaxBodyBytes int64
+}
+
+// RejectReplays wraps h so that protected requests must carry a fresh
+// timestamp and a nonce not seen within the window. Sign both, e.g. with
+// HMACAuth and its NonceHeader, so they cannot be altered. Rejected requests
+// get HTTP 401 and a CodeUnauthorized error.
+func RejectReplays(h http.Handler, opts ReplayOpts) http.Handler {
+	nonceHeader := orDefault(opts.NonceHeader, "X-Nonce")
+	tsHeader := orDefault(opts.TimestampHeader, "X-Timestamp")
+	window := opts.Window
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if len(opts.Methods) > 0 {
+			protected, err := callsAny(w, r, opts.Methods, opts.MaxBodyBytes)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeTooLarge(w, tooLarge.Limit)
+				return
+			}
+			if err == nil && !protected {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+		if err := checkFresh(r, nonceHeader, tsHeader, window, opts.Store); err != nil {
+			writeUnauthorized(w, err.Error())
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// checkFresh verifies the timestamp and records the nonce of r.
+func checkFresh(r *http.Request, nonceHeader, tsHeader string, window time.Duration, store NonceStore) error {
+	nonce := r.Header.Get(nonceHeader)
+	if nonce == "" {
+		return errors.New("missing nonce")
+	}
+	sec, err := strconv.ParseInt(r.Header.Get(tsHeader), 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid timestamp")
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > window || d < -window {
+		return errors.New("timestamp outside freshness window")
+	}
+	fresh, err := store.Add(r.Context(), nonce, 2*window)
+	if err != nil {
+		return fmt.Errorf("nonce store: %w", err)
+	}
+	if !fresh {
+		return errors.New("replayed nonce")
+	}
+	return nil
+}
+
+// callsAny reports whether the request or batch in r's body calls one of
+// methods. It reads up to limit bytes of the body and restores it for the
+// next handler.
+func callsAny(w http.ResponseWriter, r *http.Request, methods []string, limit int64) (bool, error) {
+	body, err := peekBody(w, r, limit)
+	if err != nil {
+		return false, err
+	}
+	var calls []struct {
+		Method string `json:"method"`
+	}
+	protected := false
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] != '[' {
+		trimmed = append(append([]byte{'['}, trimmed...), ']')
+	}
+	if err := json.Unmarshal(trimmed, &calls); err != nil {
+		return false, err
+	}
+	for _, c := range calls {
+		protected = protected || slices.Contains(methods, c.Method)
+	}
+	return protected, nil
+}
+
+// MemoryNonceStore is an in-process NonceStore for a single server.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	swept  time.Time
+}
+
+// NewMemoryNonceStore creates an empty MemoryNonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Add implements NonceStore.
+func (s *MemoryNonceStore) Add(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.swept) >= ttl {
+		s.swept = now
+		for n, exp := range s.nonces {
+			if !now.Before(exp) {
+				delete(s.nonces, n)
+			}
+		}
+	}
+	if exp, ok := s.nonces[nonce]; ok && now.Before(exp) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// redisNonceScript records a nonce unless it exists.
+const redisNonceScript = `
+if redis.call("SET", KEYS[1], 1, "NX", "PX", ARGV[1]) then return 1 end
+return 0`
+
+// RedisNonceStore is a NonceStore shared by every server using the same
+// Redis.
+type RedisNonceStore struct {
+	Client RedisScripter
+	// Prefix is prepended to every nonce; it defaults to "jsonrpc:nonce:".
+	Prefix string
+}
+
+// Add implements NonceStore.
+func (s *RedisNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	prefix := orDefault(s.Prefix, "jsonrpc:nonce:")
+	res, err := s.Client.Eval(ctx, redisNonceScript, []string{prefix + nonce}, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	n, ok := res.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected redis reply %v", res)
+	}
+	return n == 1, nil
+}
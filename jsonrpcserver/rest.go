@@ -0,0 +1,189 @@
+package jsonrpcserver
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// RESTOpts configures the REST bridge served by Mux.RESTHandler.
+type RESTOpts struct {
+	// Prefix is the path the endpoints live under; it defaults to "/api/".
+	Prefix string
+	// GetMethods lists the safe methods that may also be called with GET
+	// and query parameters; other methods only accept POST.
+	GetMethods []string
+}
+
+// RESTEndpoint describes an endpoint of the REST bridge.
+type RESTEndpoint struct {
+	Method  string   `json:"method"`
+	Path    string   `json:"path"`
+	Allowed []string `json:"allowed"`
+}
+
+// RESTHandler serves the registered methods as REST-style endpoints for
+// clients that cannot speak JSON-RPC. POST {prefix}{method} takes the
+// params as its body, which must be sent as application/json so that
+// browsers cannot forge the call from another site. Methods listed in
+// RESTOpts.GetMethods also take GET, with the params as query parameters
+// decoded as JSON when they parse and as strings otherwise; slashes after
+// the prefix stand for dots, so /api/user/get calls "user.get". Results are
+// written as the JSON body; errors as {"error": {...}} with an HTTP status
+// derived from the error code. GET {prefix} lists the endpoints.
+func (m *Mux) RESTHandler(opts RESTOpts) http.Handler {
+	prefix := orDefault(opts.Prefix, "/api/")
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	allowGet := func(method string) bool {
+		return slices.Contains(opts.GetMethods, method)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(correlate(w, r))
+		path, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			writeRESTError(w, NewError(CodeMethodNotFound, "method not found", r.URL.Path))
+			return
+		}
+		if path == "" && r.Method == http.MethodGet {
+			writeResponse(w, http.StatusOK, m.restEndpoints(prefix, allowGet))
+			return
+		}
+		method := strings.ReplaceAll(strings.Trim(path, "/"), "/", ".")
+		allowed := []string{http.MethodPost}
+		if allowGet(method) {
+			allowed = append(allowed, http.MethodGet)
+		}
+		if !slices.Contains(allowed, r.Method) {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			e := NewError(CodeInvalidRequest, "invalid request", r.Method+" is not allowed")
+			writeResponse(w, http.StatusMethodNotAllowed, map[string]*Error{"error": e})
+			return
+		}
+		if r.Method == http.MethodPost {
+			if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+				e := NewError(CodeInvalidRequest, "invalid request", "Content-Type must be application/json")
+				writeResponse(w, http.StatusUnsupportedMediaType, map[string]*Error{"error": e})
+				return
+			}
+		}
+		params, err := m.restParams(w, r)
+		if err != nil {
+			writeRESTError(w, err)
+			return
+		}
+		resp := m.serveCall(w, r, method, params)
+		if resp.Error != nil {
+			writeRESTError(w, resp.Error)
+			return
+		}
+		writeResponse(w, http.StatusOK, resp.Result)
+	})
+}
+
+// restParams reads the params of a REST call: the body of a POST or the
+// query of a GET.
+func (m *Mux) restParams(w http.ResponseWriter, r *http.Request) (json.RawMessage, error) {
+	if r.Method == http.MethodGet {
+		return queryParams(r.URL.Query())
+	}
+	data, err := peekBody(w, r, m.maxRequestBytes)
+	if err != nil {
+		return nil, WrapError(CodeInvalidRequest, "invalid request", err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+	if !json.Valid(data) {
+		return nil, NewError(CodeParseError, "parse error", "body is not valid JSON")
+	}
+	return data, nil
+}
+
+// queryParams converts query parameters to a params object. Repeated
+// parameters become arrays.
+func queryParams(q url.Values) (json.RawMessage, error) {
+	if len(q) == 0 {
+		return nil, nil
+	}
+	obj := make(map[string]any, len(q))
+	for name, values := range q {
+		decoded := make([]any, len(values))
+		for i, v := range values {
+			decoded[i] = queryValue(v)
+		}
+		if len(decoded) == 1 {
+			obj[name] = decoded[0]
+		} else {
+			obj[name] = decoded
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// queryValue decodes a query value as JSON, falling back to the string.
+func queryValue(v string) any {
+	var raw json.RawMessage
+	if json.Unmarshal([]byte(v), &raw) == nil {
+		return raw
+	}
+	return v
+}
+
+// restEndpoints lists the endpoints of the REST bridge.
+func (m *Mux) restEndpoints(prefix string, allowGet func(string) bool) []RESTEndpoint {
+	methods := slices.Sorted(maps.Keys(m.allHandlers()))
+	out := make([]RESTEndpoint, 0, len(methods))
+	for _, method := range methods {
+		e := RESTEndpoint{Method: method, Path: prefix + method, Allowed: []string{http.MethodPost}}
+		if allowGet(method) {
+			e.Allowed = append(e.Allowed, http.MethodGet)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// restStatus maps an error code to the HTTP status of a REST response.
+func restStatus(code int) int {
+	switch code {
+	case CodeParseError, CodeInvalidRequest, CodeInvalidParams:
+		return http.StatusBadRequest
+	case CodeMethodNotFound, CodeNotFound:
+		return http.StatusNotFound
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeLimitExceeded, CodeQuotaExceeded:
+		return http.StatusTooManyRequests
+	case CodeUnavailable, CodeBusy:
+		return http.StatusServiceUnavailable
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	}
+	if code <= -32000 && code >= -32768 {
+		return http.StatusInternalServerError
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// writeRESTError writes err as a REST error response.
+func writeRESTError(w http.ResponseWriter, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = NewError(CodeInternalError, "internal error", nil)
+	}
+	status := restStatus(e.Code)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	writeResponse(w, status, map[string]*Error{"error": e})
+}
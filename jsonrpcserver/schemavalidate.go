@@ -0,0 +1,273 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// SchemaViolation is a single params validation failure at a JSON path such
+// as "params.user.age" or "params[1]".
+type SchemaViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ParseOpenRPC decodes an OpenRPC document.
+func ParseOpenRPC(data []byte) (*OpenRPCDocument, error) {
+	var doc OpenRPCDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse openrpc document: %w", err)
+	}
+	return &doc, nil
+}
+
+// ValidateParams returns middleware that checks the params of every method
+// described by doc against its param descriptors before the handler runs.
+// Requests that fail get -32602 Invalid params with the []SchemaViolation
+// in data. The supported schema keywords are type, enum, properties,
+// required, additionalProperties, items, minimum, maximum, minLength,
+// maxLength, pattern, minItems and maxItems.
+func ValidateParams(doc *OpenRPCDocument) Middleware {
+	methods := make(map[string]*OpenRPCMethod, len(doc.Methods))
+	for i := range doc.Methods {
+		methods[doc.Methods[i].Name] = &doc.Methods[i]
+	}
+	var patterns sync.Map
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			req := RequestFromContext(ctx)
+			if req == nil {
+				return next.ServeRPC(ctx, params)
+			}
+			method, ok := methods[req.Method]
+			if !ok {
+				return next.ServeRPC(ctx, params)
+			}
+			var g any
+			if len(params) > 0 {
+				dec := json.NewDecoder(bytes.NewReader(params))
+				dec.UseNumber()
+				if err := dec.Decode(&g); err != nil {
+					return nil, ErrInvalidParams(err.Error())
+				}
+			}
+			v := schemaChecker{patterns: &patterns}
+			v.checkMethod(method, g)
+			if len(v.violations) > 0 {
+				return nil, ErrInvalidParams(v.violations)
+			}
+			return next.ServeRPC(ctx, params)
+		})
+	}
+}
+
+// schemaChecker collects violations while walking a value.
+type schemaChecker struct {
+	violations []SchemaViolation
+	patterns   *sync.Map
+}
+
+// fail records a violation at path.
+func (v *schemaChecker) fail(path, format string, args ...any) {
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkMethod checks params against the method's param descriptors.
+func (v *schemaChecker) checkMethod(m *OpenRPCMethod, params any) {
+	switch p := params.(type) {
+	case []any:
+		if m.ParamStructure == "by-name" {
+			v.fail("params", "expected named params")
+			return
+		}
+		if len(p) > len(m.Params) {
+			v.fail("params", "got %d params, want at most %d", len(p), len(m.Params))
+		}
+		for i, d := range m.Params {
+			path := "params[" + strconv.Itoa(i) + "]"
+			if i >= len(p) {
+				if d.Required {
+					v.fail(path, "required param %q is missing", d.Name)
+				}
+				continue
+			}
+			v.check(d.Schema, p[i], path)
+		}
+	case map[string]any:
+		if m.ParamStructure == "by-position" {
+			v.fail("params", "expected positional params")
+			return
+		}
+		for _, d := range m.Params {
+			val, ok := p[d.Name]
+			if !ok {
+				if d.Required {
+					v.fail("params."+d.Name, "is required")
+				}
+				continue
+			}
+			v.check(d.Schema, val, "params."+d.Name)
+		}
+	case nil:
+		for _, d := range m.Params {
+			if d.Required {
+				v.fail("params", "required param %q is missing", d.Name)
+			}
+		}
+	default:
+		v.fail("params", "expected an array or object, got %s", jsonType(params))
+	}
+}
+
+// check validates the generic JSON value g against schema s.
+func (v *schemaChecker) check(s map[string]any, g any, path string) {
+	if s == nil {
+		return
+	}
+	if types := schemaTypes(s["type"]); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return isJSONType(g, t) }) {
+		v.fail(path, "expected %s, got %s", strings.Join(types, " or "), jsonType(g))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return sameJSON(e, g) }) {
+		v.fail(path, "value %v is not one of %v", g, enum)
+	}
+	switch val := g.(type) {
+	case json.Number:
+		f, _ := val.Float64()
+		if lo, ok := s["minimum"].(float64); ok && f < lo {
+			v.fail(path, "%v is less than minimum %v", val, lo)
+		}
+		if hi, ok := s["maximum"].(float64); ok && f > hi {
+			v.fail(path, "%v is greater than maximum %v", val, hi)
+		}
+	case string:
+		n := float64(utf8.RuneCountInString(val))
+		if lo, ok := s["minLength"].(float64); ok && n < lo {
+			v.fail(path, "length %v is less than minLength %v", n, lo)
+		}
+		if hi, ok := s["maxLength"].(float64); ok && n > hi {
+			v.fail(path, "length %v is greater than maxLength %v", n, hi)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := v.compile(pattern)
+			if err != nil {
+				v.fail(path, "invalid schema pattern %q: %v", pattern, err)
+			} else if !re.MatchString(val) {
+				v.fail(path, "%q does not match pattern %q", val, pattern)
+			}
+		}
+	case []any:
+		n := float64(len(val))
+		if lo, ok := s["minItems"].(float64); ok && n < lo {
+			v.fail(path, "%v items is less than minItems %v", n, lo)
+		}
+		if hi, ok := s["maxItems"].(float64); ok && n > hi {
+			v.fail(path, "%v items is more than maxItems %v", n, hi)
+		}
+		items, _ := s["items"].(map[string]any)
+		for i, item := range val {
+			v.check(items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	case map[string]any:
+		if required, ok := s["required"].([]any); ok {
+			for _, name := range required {
+				if name, ok := name.(string); ok {
+					if _, ok := val[name]; !ok {
+						v.fail(path+"."+name, "is required")
+					}
+				}
+			}
+		}
+		props, _ := s["properties"].(map[string]any)
+		closed := s["additionalProperties"] == false
+		for _, name := range slices.Sorted(maps.Keys(val)) {
+			if ps, ok := props[name].(map[string]any); ok {
+				v.check(ps, val[name], path+"."+name)
+			} else if closed {
+				v.fail(path+"."+name, "is not allowed")
+			}
+		}
+	}
+}
+
+// compile compiles pattern once per middleware.
+func (v *schemaChecker) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.patterns.Store(pattern, re)
+	return re, nil
+}
+
+// schemaTypes reads a type keyword given as a name or a list of names.
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var out []string
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				out = append(out, name)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// isJSONType reports whether g is of JSON Schema type t.
+func isJSONType(g any, t string) bool {
+	if t == "integer" {
+		n, ok := g.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	}
+	return jsonType(g) == t
+}
+
+// jsonType names the JSON Schema type of a generic JSON value.
+func jsonType(g any) string {
+	switch g.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", g)
+}
+
+// sameJSON compares an enum member with a generic JSON value.
+func sameJSON(a, b any) bool {
+	if n, ok := b.(json.Number); ok {
+		f, _ := n.Float64()
+		af, ok := a.(float64)
+		return ok && af == f
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,94 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+// scopedHandler is a handler that declares the scopes its callers need.
+type scopedHandler struct {
+	Handler
+	scopes []string
+}
+
+// RequireScopes declares that callers of h need every one of scopes. The
+// Authorize middleware enforces the declaration.
+func RequireScopes(h Handler, scopes ...string) Handler {
+	return scopedHandler{Handler: h, scopes: scopes}
+}
+
+// HandleScoped registers h for method, requiring scopes of its callers.
+func (m *Mux) HandleScoped(method string, h Handler, scopes ...string) {
+	m.Handle(method, RequireScopes(h, scopes...))
+}
+
+// describe documents the wrapped handler.
+func (h scopedHandler) describe(name string) OpenRPCMethod {
+	if d, ok := h.Handler.(describer); ok {
+		return d.describe(name)
+	}
+	return OpenRPCMethod{Name: name, Params: []OpenRPCContentDescriptor{}}
+}
+
+// scopesOf returns the scopes declared by h for the call with params,
+// looking through mounts, groups and the version the call selects.
+func scopesOf(ctx context.Context, h Handler, params json.RawMessage) []string {
+	switch h := h.(type) {
+	case scopedHandler:
+		return h.scopes
+	case mountedHandler:
+		return scopesOf(ctx, h.inner, params)
+	case groupHandler:
+		return scopesOf(ctx, h.inner, params)
+	case *versionedHandler:
+		v, ok := h.selected(ctx, params)
+		if !ok {
+			return h.scopes
+		}
+		if scopes := scopesOf(ctx, v, params); scopes != nil {
+			return scopes
+		}
+		return h.scopes
+	}
+	return nil
+}
+
+// scopesKey is the context key under which the required scopes are stored.
+type scopesKey struct{}
+
+// RequiredScopes returns the scopes declared by the handler of the request
+// being served.
+func RequiredScopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopesKey{}).([]string)
+	return scopes
+}
+
+// Authorize returns middleware that checks the scopes declared with
+// RequireScopes against the authenticated Principal. Callers without a
+// Principal get CodeUnauthorized and callers missing a scope CodeForbidden,
+// whose data lists the missing scopes.
+func Authorize() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+			required := RequiredScopes(ctx)
+			if len(required) == 0 {
+				return next.ServeRPC(ctx, params)
+			}
+			p := PrincipalFromContext(ctx)
+			if p == nil {
+				return nil, NewError(CodeUnauthorized, "unauthorized", "authentication required")
+			}
+			var missing []string
+			for _, s := range required {
+				if !slices.Contains(p.Scopes, s) {
+					missing = append(missing, s)
+				}
+			}
+			if len(missing) > 0 {
+				return nil, NewError(CodeForbidden, "forbidden", map[string]any{"missing": missing})
+			}
+			return next.ServeRPC(ctx, params)
+		})
+	}
+}
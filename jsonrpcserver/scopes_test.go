@@ -0,0 +1,72 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// callWith serves body on m as principal p and returns the error code of
+// the response, or zero on success.
+func callWith(t *testing.T, m *Mux, p *Principal, body string) int {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	if p != nil {
+		r = r.WithContext(WithPrincipal(r.Context(), p))
+	}
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	var resp struct {
+		Error *Error `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	if resp.Error == nil {
+		return 0
+	}
+	return resp.Error.Code
+}
+
+func ok(context.Context, json.RawMessage) (any, error) { return "ok", nil }
+
+func TestAuthorizeVersionedScopes(t *testing.T) {
+	m := NewMux()
+	m.Use(Authorize())
+	m.HandleVersion("admin.reset", "v1", RequireScopes(HandlerFunc(ok), "admin"))
+	m.HandleVersion("admin.reset", "v2", RequireScopes(HandlerFunc(ok), "admin", "reset"))
+
+	tests := []struct {
+		name string
+		p    *Principal
+		body string
+		want int
+	}{
+		{"no principal", nil, `{"method":"admin.reset","id":1}`, CodeUnauthorized},
+		{"missing scope", &Principal{ID: "u"}, `{"method":"admin.reset","id":1}`, CodeForbidden},
+		{"default version", &Principal{ID: "u", Scopes: []string{"admin"}}, `{"method":"admin.reset","id":1}`, 0},
+		{"selected version", &Principal{ID: "u", Scopes: []string{"admin"}}, `{"method":"admin.reset","params":{"version":"v2"},"id":1}`, CodeForbidden},
+		{"selected version granted", &Principal{ID: "u", Scopes: []string{"admin", "reset"}}, `{"method":"admin.reset","params":{"version":"v2"},"id":1}`, 0},
+	}
+	for _, tt := range tests {
+		if got := callWith(t, m, tt.p, tt.body); got != tt.want {
+			t.Errorf("%s: code = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHandleVersionKeepsScopes(t *testing.T) {
+	m := NewMux()
+	m.Use(Authorize())
+	m.HandleScoped("admin.reset", HandlerFunc(ok), "admin")
+	m.HandleVersion("admin.reset", "v2", HandlerFunc(ok))
+
+	if got := callWith(t, m, &Principal{ID: "u"}, `{"method":"admin.reset","id":1}`); got != CodeForbidden {
+		t.Errorf("code = %d, want %d", got, CodeForbidden)
+	}
+	if got := callWith(t, m, &Principal{ID: "u", Scopes: []string{"admin"}}, `{"method":"admin.reset","id":1}`); got != 0 {
+		t.Errorf("code = %d, want success", got)
+	}
+}
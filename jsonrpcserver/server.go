@@ -0,0 +1,171 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server serves an http.Handler, usually a Mux, and shuts down gracefully:
+// new requests are refused while requests already running and tracked
+// long-lived connections are allowed to finish.
+type Server struct {
+	httpServer *http.Server
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	mu       sync.Mutex
+	closing  bool
+	inflight sync.WaitGroup
+	conns    map[*trackedConn]struct{}
+}
+
+// ServerOpts contains options for creating a Server.
+type ServerOpts struct {
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
+
+// trackedConn is a long-lived connection registered with Track.
+type trackedConn struct {
+	io.Closer
+}
+
+// NewServer creates a Server for h listening on addr with default options.
+func NewServer(addr string, h http.Handler) *Server {
+	return NewServerWithOpts(addr, h, nil)
+}
+
+// NewServerWithOpts creates a Server for h listening on addr with custom options.
+func NewServerWithOpts(addr string, h http.Handler, opts *ServerOpts) *Server {
+	s := &Server{conns: make(map[*trackedConn]struct{})}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.httpServer = &http.Server{
+		Addr:        addr,
+		Handler:     s.track(h),
+		BaseContext: func(net.Listener) context.Context { return withServer(s.ctx, s) },
+	}
+	if opts != nil {
+		s.httpServer.ReadTimeout = opts.ReadTimeout
+		s.httpServer.WriteTimeout = opts.WriteTimeout
+		s.httpServer.IdleTimeout = opts.IdleTimeout
+	}
+	return s
+}
+
+// HTTPServer returns the underlying http.Server for further configuration
+// before serving.
+func (s *Server) HTTPServer() *http.Server {
+	return s.httpServer
+}
+
+// ListenAndServe listens on the configured address and serves requests. It
+// returns http.ErrServerClosed after Shutdown.
+func (s *Server) ListenAndServe() error {
+	return s.httpServer.ListenAndServe()
+}
+
+// Serve serves requests accepted on l. It returns http.ErrServerClosed after
+// Shutdown.
+func (s *Server) Serve(l net.Listener) error {
+	return s.httpServer.Serve(l)
+}
+
+// track wraps h so that in-flight requests are counted and requests arriving
+// during shutdown are refused.
+func (s *Server) track(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.begin() {
+			w.Header().Set("Connection", "close")
+			writeResponse(w, http.StatusServiceUnavailable, &Response{Error: NewError(CodeUnavailable, "server shutting down", nil)})
+			return
+		}
+		defer s.inflight.Done()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// begin registers an in-flight request unless the server is shutting down.
+func (s *Server) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.inflight.Add(1)
+	return true
+}
+
+// Track registers a long-lived connection, such as a subscription stream,
+// that Shutdown waits for and closes once its deadline passes. The returned
+// function unregisters it and must be called when the connection ends. ok is
+// false when the server is already shutting down.
+func (s *Server) Track(c io.Closer) (untrack func(), ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return func() {}, false
+	}
+	tc := &trackedConn{c}
+	s.conns[tc] = struct{}{}
+	s.inflight.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.conns, tc)
+			s.mu.Unlock()
+			s.inflight.Done()
+		})
+	}, true
+}
+
+// Shutdown stops accepting new requests and waits for in-flight requests and
+// tracked connections to finish. When ctx ends first, the contexts of the
+// remaining handlers are canceled, tracked connections and the listener are
+// closed forcibly, and ctx's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+	err := s.httpServer.Shutdown(ctx)
+	select {
+	case <-done:
+		s.cancel()
+		return err
+	case <-ctx.Done():
+	}
+	s.cancel()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	return errors.Join(ctx.Err(), s.httpServer.Close())
+}
+
+// serverKey is the context key under which the serving Server is stored.
+type serverKey struct{}
+
+// withServer returns a copy of ctx carrying s.
+func withServer(ctx context.Context, s *Server) context.Context {
+	return context.WithValue(ctx, serverKey{}, s)
+}
+
+// ServerFromContext returns the Server serving the request, or nil when the
+// handler is not run by a Server.
+func ServerFromContext(ctx context.Context) *Server {
+	s, _ := ctx.Value(serverKey{}).(*Server)
+	return s
+}
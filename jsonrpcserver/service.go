@@ -0,0 +1,96 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+)
+
+// RegisterService exposes the exported methods of receiver that have the form
+//
+//	func (s *T) Method(ctx context.Context, args *Args) (*Reply, error)
+//
+// as "name.Method", mirroring net/rpc. Args and Reply may be any types that
+// encoding/json handles; params may be the args value itself or, unless Args
+// is a slice, a one-element array holding it. Methods of other shapes are
+// skipped. It fails if no method qualifies.
+func (m *Mux) RegisterService(name string, receiver any) error {
+	rv := reflect.ValueOf(receiver)
+	rt := rv.Type()
+	registered := 0
+	for i := range rt.NumMethod() {
+		method := rt.Method(i)
+		if !method.IsExported() || !isServiceMethod(method.Type) {
+			continue
+		}
+		m.Handle(name+"."+method.Name, serviceHandler{fn: rv.Method(i), args: method.Type.In(2), result: method.Type.Out(0)})
+		registered++
+	}
+	if registered == 0 {
+		return fmt.Errorf("jsonrpcserver: type %v has no methods of the form func(context.Context, *Args) (*Reply, error)", rt)
+	}
+	return nil
+}
+
+// isServiceMethod reports whether t, including its receiver, has the shape
+// RegisterService accepts.
+func isServiceMethod(t reflect.Type) bool {
+	return t.NumIn() == 3 && t.In(1) == contextType &&
+		t.NumOut() == 2 && t.Out(1) == errorType
+}
+
+// serviceHandler calls a registered service method through reflection.
+type serviceHandler struct {
+	fn     reflect.Value
+	args   reflect.Type
+	result reflect.Type
+}
+
+// describe documents the method's params and result.
+func (h serviceHandler) describe(name string) OpenRPCMethod {
+	return describeArgs(name, h.args, h.result)
+}
+
+// ServeRPC decodes params into the method's argument type and calls it.
+func (h serviceHandler) ServeRPC(ctx context.Context, params json.RawMessage) (any, error) {
+	argType := h.args
+	if argType.Kind() == reflect.Pointer {
+		argType = argType.Elem()
+	}
+	arg := reflect.New(argType)
+	unwrap := argType.Kind() != reflect.Slice && argType.Kind() != reflect.Array
+	if err := decodeServiceArgs(params, arg.Interface(), unwrap); err != nil {
+		return nil, ErrInvalidParams(err.Error())
+	}
+	if h.args.Kind() != reflect.Pointer {
+		arg = arg.Elem()
+	}
+	out := h.fn.Call([]reflect.Value{reflect.ValueOf(ctx), arg})
+	if errV := out[1]; !errV.IsNil() {
+		return nil, errV.Interface().(error)
+	}
+	return out[0].Interface(), nil
+}
+
+// decodeServiceArgs unmarshals params into to, unwrapping a one-element
+// array when unwrap is set.
+func decodeServiceArgs(params json.RawMessage, to any, unwrap bool) error {
+	if len(params) == 0 {
+		return nil
+	}
+	var arr []json.RawMessage
+	if unwrap && json.Unmarshal(params, &arr) == nil {
+		if len(arr) != 1 {
+			return errors.New("expected a single argument")
+		}
+		params = arr[0]
+	}
+	return json.Unmarshal(params, to)
+}
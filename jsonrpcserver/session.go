@@ -0,0 +1,295 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSessionClosed is returned when pushing to a closed session.
+var ErrSessionClosed = errors.New("rpc session closed")
+
+// ErrNotificationDropped is returned when backpressure discarded a
+// notification.
+var ErrNotificationDropped = errors.New("rpc notification dropped by backpressure")
+
+// Notification is a JSON-RPC notification pushed by the server.
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Session is a WebSocket connection to one client. Requests arriving on it
+// are served with the Session in their context.
+type Session struct {
+	id   string
+	conn *wsConn
+	ctx  context.Context
+	// out queues pushed notifications, subject to the backpressure policy;
+	// replies queues responses, which are never dropped.
+	out     chan []byte
+	replies chan []byte
+
+	policy       BackpressurePolicy
+	blockTimeout time.Duration
+	dropped      atomic.Uint64
+	counters     *hubCounters
+
+	mu     sync.Mutex
+	subs   map[string]*Subscription
+	values map[any]any
+	closed bool
+}
+
+// sessionKey is the context key under which the Session is stored.
+type sessionKey struct{}
+
+// SessionFromContext returns the session the request arrived on, or nil for
+// plain HTTP requests.
+func SessionFromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// ID returns the random identifier of the session.
+func (s *Session) ID() string { return s.id }
+
+// Context returns a context canceled when the session ends.
+func (s *Session) Context() context.Context { return s.ctx }
+
+// Notify pushes a notification to the client, subject to the backpressure
+// policy when the send queue is full.
+func (s *Session) Notify(method string, params any) error {
+	data, err := json.Marshal(Notification{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if s.trySend(data) {
+		return nil
+	}
+	if s.ctx.Err() != nil {
+		return ErrSessionClosed
+	}
+	return ErrNotificationDropped
+}
+
+// send encodes v and queues it, waiting for room in the send queue.
+func (s *Session) send(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.replies <- data:
+		return nil
+	case <-s.ctx.Done():
+		return ErrSessionClosed
+	}
+}
+
+// writeLoop sends queued messages until the session ends, responses ahead
+// of notifications.
+func (s *Session) writeLoop() {
+	for {
+		var data []byte
+		select {
+		case data = <-s.replies:
+		default:
+			select {
+			case data = <-s.replies:
+			case data = <-s.out:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+		if s.conn.writeMessage(data) != nil {
+			return
+		}
+	}
+}
+
+// Subscribe creates a subscription whose updates are pushed as notifications
+// of method. Handlers return its ID to the client.
+func (s *Session) Subscribe(method string) (*Subscription, error) {
+	return s.subscribe(method, "")
+}
+
+// subscribe creates a subscription, for topic pattern when it is not empty.
+func (s *Session) subscribe(method, pattern string) (*Subscription, error) {
+	sub := &Subscription{ID: newID(), method: method, session: s, done: make(chan struct{}), pattern: pattern}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrSessionClosed
+	}
+	s.subs[sub.ID] = sub
+	return sub, nil
+}
+
+// Unsubscribe ends the subscription with id and reports whether it existed.
+func (s *Session) Unsubscribe(id string) bool {
+	s.mu.Lock()
+	sub, ok := s.subs[id]
+	delete(s.subs, id)
+	s.mu.Unlock()
+	if ok {
+		close(sub.done)
+	}
+	return ok
+}
+
+// Subscriptions returns the active subscriptions of the session.
+func (s *Session) Subscriptions() []*Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := make([]*Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// close ends every subscription.
+func (s *Session) close() {
+	s.mu.Lock()
+	subs := s.subs
+	s.subs, s.closed = nil, true
+	s.mu.Unlock()
+	for _, sub := range subs {
+		close(sub.done)
+	}
+	s.clearValues()
+}
+
+// Subscription streams updates to the session that created it.
+type Subscription struct {
+	ID      string
+	method  string
+	session *Session
+	done    chan struct{}
+	// pattern is the topic pattern of subscriptions made by EnableTopics.
+	pattern string
+}
+
+// SubscriptionUpdate is the params of a subscription notification.
+type SubscriptionUpdate struct {
+	Subscription string `json:"subscription"`
+	Result       any    `json:"result"`
+}
+
+// Notify pushes result to the client as an update of the subscription.
+func (sub *Subscription) Notify(result any) error {
+	select {
+	case <-sub.done:
+		return ErrSessionClosed
+	default:
+	}
+	return sub.session.Notify(sub.method, SubscriptionUpdate{Subscription: sub.ID, Result: result})
+}
+
+// Done is closed when the subscription is canceled or its session ends.
+func (sub *Subscription) Done() <-chan struct{} { return sub.done }
+
+// Session returns the session owning the subscription.
+func (sub *Subscription) Session() *Session { return sub.session }
+
+// Subscribe creates a subscription on the session of ctx. It fails with a
+// CodeInvalidRequest error for requests not made over WebSocket.
+func Subscribe(ctx context.Context, method string) (*Subscription, error) {
+	s := SessionFromContext(ctx)
+	if s == nil {
+		return nil, NewError(CodeInvalidRequest, "invalid request", "subscriptions require a websocket connection")
+	}
+	return s.Subscribe(method)
+}
+
+// newID returns a random hex identifier.
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// serveWebSocket upgrades r and serves requests arriving on the connection
+// until it closes. Requests are served concurrently; their responses are
+// sent as they complete.
+func (m *Mux) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	check := m.checkOrigin
+	if check == nil {
+		check = sameOrigin
+	}
+	if !check(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	conn, err := upgrade(w, r, m.maxRequestBytes)
+	if err != nil {
+		return
+	}
+	defer conn.conn.Close()
+	if srv := ServerFromContext(r.Context()); srv != nil {
+		untrack, ok := srv.Track(conn)
+		if !ok {
+			conn.Close()
+			return
+		}
+		defer untrack()
+	}
+	ctx, cancel := context.WithCancel(m.withCaller(context.WithValue(r.Context(), httpRequestKey{}, r), r, TransportWebSocket))
+	defer cancel()
+	queue := m.sendQueueSize
+	if queue <= 0 {
+		queue = defaultSendQueue
+	}
+	sess := &Session{
+		id:           newID(),
+		conn:         conn,
+		out:          make(chan []byte, queue),
+		replies:      make(chan []byte, queue),
+		policy:       m.backpressure,
+		blockTimeout: m.backpressureTimeout,
+		counters:     &m.hub.counters,
+		subs:         make(map[string]*Subscription),
+	}
+	ctx = context.WithValue(ctx, sessionKey{}, sess)
+	sess.ctx = ctx
+	go sess.writeLoop()
+	m.hub.add(sess)
+	var wg sync.WaitGroup
+	defer func() {
+		m.hub.remove(sess)
+		cancel()
+		sess.close()
+		wg.Wait()
+	}()
+	for {
+		msg, err := conn.readMessage()
+		if err != nil {
+			return
+		}
+		msg = bytes.TrimSpace(msg)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out any
+			if !json.Valid(msg) {
+				out = &Response{Error: NewError(CodeParseError, "parse error", "invalid JSON")}
+			} else if len(msg) > 0 && msg[0] == '[' {
+				out = m.batch(ctx, msg)
+			} else if resp, notify := m.serve(ctx, msg); !notify {
+				out = resp
+			}
+			if out != nil {
+				_ = sess.send(m.stamp(out))
+			}
+		}()
+	}
+}
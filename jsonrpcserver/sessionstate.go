@@ -0,0 +1,103 @@
+package jsonrpcserver
+
+import (
+	"io"
+	"reflect"
+)
+
+// Set stores value under key for the lifetime of the session, replacing any
+// previous value. Values that implement io.Closer are closed when replaced,
+// deleted or when the session ends.
+func (s *Session) Set(key, value any) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		closeValue(value)
+		return
+	}
+	if s.values == nil {
+		s.values = make(map[any]any)
+	}
+	old, ok := s.values[key]
+	s.values[key] = value
+	s.mu.Unlock()
+	if ok && !sameValue(old, value) {
+		closeValue(old)
+	}
+}
+
+// sameValue reports whether old is value stored again, comparing only
+// values of comparable types; slices, maps and funcs are never the same.
+func sameValue(old, value any) bool {
+	t := reflect.TypeOf(old)
+	return t != nil && t.Comparable() && old == value
+}
+
+// Get returns the value stored under key.
+func (s *Session) Get(key any) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key any) {
+	s.mu.Lock()
+	old, ok := s.values[key]
+	delete(s.values, key)
+	s.mu.Unlock()
+	if ok {
+		closeValue(old)
+	}
+}
+
+// clearValues drops every stored value once the session has ended.
+func (s *Session) clearValues() {
+	s.mu.Lock()
+	values := s.values
+	s.values = nil
+	s.mu.Unlock()
+	for _, v := range values {
+		closeValue(v)
+	}
+}
+
+// closeValue closes v when it is an io.Closer.
+func closeValue(v any) {
+	if c, ok := v.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// SessionKey is a typed key for Session values, so handlers sharing state
+// agree on its type. Distinct keys never collide, even with the same name.
+type SessionKey[T any] struct {
+	name *string
+}
+
+// NewSessionKey returns a new key; name is only used for debugging.
+func NewSessionKey[T any](name string) SessionKey[T] {
+	return SessionKey[T]{name: &name}
+}
+
+// String returns the name of the key.
+func (k SessionKey[T]) String() string { return *k.name }
+
+// Get returns the value stored under k in s, or the zero value when there
+// is none.
+func (k SessionKey[T]) Get(s *Session) (T, bool) {
+	v, _ := s.Get(k)
+	t, ok := v.(T)
+	return t, ok
+}
+
+// Set stores v under k in s.
+func (k SessionKey[T]) Set(s *Session, v T) {
+	s.Set(k, v)
+}
+
+// Delete removes the value stored under k in s.
+func (k SessionKey[T]) Delete(s *Session) {
+	s.Delete(k)
+}
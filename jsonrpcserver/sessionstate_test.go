@@ -0,0 +1,33 @@
+package jsonrpcserver
+
+import "testing"
+
+// closer counts how often it is closed.
+type closer struct{ closed int }
+
+func (c *closer) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestSessionSetReplaces(t *testing.T) {
+	s := &Session{}
+	s.Set("tags", []string{"a"})
+	s.Set("tags", []string{"b"})
+	if v, _ := s.Get("tags"); v.([]string)[0] != "b" {
+		t.Errorf("tags = %v, want [b]", v)
+	}
+	s.Set("m", map[string]int{"a": 1})
+	s.Set("m", map[string]int{"a": 2})
+
+	c := &closer{}
+	s.Set("conn", c)
+	s.Set("conn", c)
+	if c.closed != 0 {
+		t.Errorf("value stored again was closed %d times", c.closed)
+	}
+	s.Set("conn", &closer{})
+	if c.closed != 1 {
+		t.Errorf("replaced value was closed %d times, want 1", c.closed)
+	}
+}
@@ -0,0 +1,59 @@
+package jsonrpcserver
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// validateRequest checks the members of req against the JSON-RPC 2.0
+// specification: a method name, an id that is a string, number or null,
+// and params that are an object or array. In strict mode the jsonrpc member
+// must be "2.0".
+func (m *Mux) validateRequest(req *Request) *Error {
+	invalid := func(reason string) *Error { return NewError(CodeInvalidRequest, "invalid request", reason) }
+	if m.strict && req.JSONRPC != "2.0" {
+		return invalid(`jsonrpc must be exactly "2.0"`)
+	}
+	if req.Method == "" {
+		return invalid("method is required")
+	}
+	if validID(req) == nil && req.hasID && !bytes.Equal(bytes.TrimSpace(req.rawID), []byte("null")) {
+		return invalid("id must be a string, number or null")
+	}
+	if p := bytes.TrimSpace(req.Params); len(p) > 0 && p[0] != '{' && p[0] != '[' {
+		return invalid("params must be an object or array")
+	}
+	return nil
+}
+
+// validID returns the id of req as received when it is a string or number,
+// or nil.
+func validID(req *Request) any {
+	switch req.ID.(type) {
+	case string, json.Number:
+		return json.RawMessage(bytes.TrimSpace(req.rawID))
+	}
+	return nil
+}
+
+// responseID returns the id to echo in the response: the id member exactly
+// as received, or null.
+func (r *Request) responseID() any {
+	return validID(r)
+}
+
+// stamp sets the jsonrpc member of the responses in v in strict mode.
+func (m *Mux) stamp(v any) any {
+	if !m.strict {
+		return v
+	}
+	switch v := v.(type) {
+	case *Response:
+		v.JSONRPC = "2.0"
+	case []*Response:
+		for _, r := range v {
+			r.JSONRPC = "2.0"
+		}
+	}
+	return v
+}
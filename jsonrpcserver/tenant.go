@@ -0,0 +1,128 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Tenant identifies the tenant a request is served for.
+type Tenant struct {
+	ID string
+	// Data is the tenant's data source or configuration, as loaded by
+	// TenantOpts.Load.
+	Data any
+}
+
+// tenantKey is the context key under which the Tenant is stored.
+type tenantKey struct{}
+
+// TenantFromContext returns the tenant of the request, or nil.
+func TenantFromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// TenantResolver extracts the tenant ID of an HTTP request, returning ""
+// when the request names none.
+type TenantResolver func(r *http.Request) (string, error)
+
+// TenantFromHeader resolves the tenant from an HTTP header.
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) (string, error) {
+		return r.Header.Get(name), nil
+	}
+}
+
+// TenantFromSubdomain resolves the tenant from the label in front of base,
+// e.g. "acme" for "acme.api.example.com" with base "api.example.com".
+func TenantFromSubdomain(base string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.TrimPrefix(base, "."))
+	return func(r *http.Request) (string, error) {
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		sub, ok := strings.CutSuffix(host, suffix)
+		if !ok || sub == "" || strings.Contains(sub, ".") {
+			return "", nil
+		}
+		return sub, nil
+	}
+}
+
+// TenantFromAPIKey resolves the tenant owning the API key sent in header.
+func TenantFromAPIKey(header string, lookup func(ctx context.Context, key string) (string, error)) TenantResolver {
+	return func(r *http.Request) (string, error) {
+		key := r.Header.Get(header)
+		if key == "" {
+			return "", nil
+		}
+		return lookup(r.Context(), key)
+	}
+}
+
+// TenantOpts configures the Tenants wrapper.
+type TenantOpts struct {
+	// Resolvers are tried in order until one names a tenant.
+	Resolvers []TenantResolver
+	// Required rejects requests that name no tenant.
+	Required bool
+	// Load, when set, loads the tenant's Data; an error rejects the request.
+	Load func(ctx context.Context, id string) (any, error)
+	// Mux, when set, returns the handler set of a tenant; a nil result
+	// falls back to the wrapped handler.
+	Mux func(id string) *Mux
+}
+
+// errUnknownTenant is reported for requests naming no tenant.
+var errUnknownTenant = errors.New("tenant required")
+
+// Tenants wraps h so that the tenant of every request is resolved and
+// placed in its context, and requests are routed to the tenant's own Mux
+// when TenantOpts.Mux provides one. Requests whose tenant cannot be
+// resolved get HTTP 400 and a CodeInvalidRequest error.
+func Tenants(h http.Handler, opts TenantOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := resolveTenant(r, opts.Resolvers)
+		if err == nil && id == "" && opts.Required {
+			err = errUnknownTenant
+		}
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, &Response{Error: NewError(CodeInvalidRequest, "invalid request", err.Error())})
+			return
+		}
+		if id == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		t := &Tenant{ID: id}
+		if opts.Load != nil {
+			if t.Data, err = opts.Load(r.Context(), id); err != nil {
+				writeResponse(w, http.StatusBadRequest, &Response{Error: NewError(CodeInvalidRequest, "invalid request", err.Error())})
+				return
+			}
+		}
+		r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, t))
+		if opts.Mux != nil {
+			if m := opts.Mux(id); m != nil {
+				m.ServeHTTP(w, r)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// resolveTenant returns the first tenant ID named by the resolvers.
+func resolveTenant(r *http.Request, resolvers []TenantResolver) (string, error) {
+	for _, resolve := range resolvers {
+		id, err := resolve(r)
+		if err != nil || id != "" {
+			return id, err
+		}
+	}
+	return "", nil
+}
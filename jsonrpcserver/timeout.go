@@ -0,0 +1,49 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// SetMethodTimeout bounds the execution of method to d, overriding
+// MuxOpts.Timeout. A zero d disables the timeout for method.
+func (m *Mux) SetMethodTimeout(method string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.methodTimeouts[method] = d
+}
+
+// timeoutFor returns the execution timeout of method, or zero for none.
+func (m *Mux) timeoutFor(method string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if d, ok := m.methodTimeouts[method]; ok {
+		return d
+	}
+	return m.timeout
+}
+
+// withTimeout runs h with its context canceled after d. The response is sent
+// when d passes even if h ignores the cancellation and keeps running.
+func withTimeout(h Handler, d time.Duration) Handler {
+	return HandlerFunc(func(ctx context.Context, params json.RawMessage) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		type outcome struct {
+			result any
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := h.ServeRPC(ctx, params)
+			done <- outcome{result, err}
+		}()
+		select {
+		case o := <-done:
+			return o.result, o.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+}
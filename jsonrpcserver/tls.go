@@ -0,0 +1,120 @@
+package jsonrpcserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+)
+
+// TLSOpts configures a Server for TLS.
+type TLSOpts struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAs verifies client certificates; ClientCAFile is read into it
+	// when set.
+	ClientCAs    *x509.CertPool
+	ClientCAFile string
+	// RequireClientCert rejects connections without a valid client
+	// certificate. Without it, certificates are verified when presented.
+	RequireClientCert bool
+	// CertPrincipal maps the verified client certificate to the Principal
+	// placed in the request context. Requests whose certificate it rejects
+	// get HTTP 401.
+	CertPrincipal func(cert *x509.Certificate) (*Principal, error)
+}
+
+// TLSConfig builds a tls.Config with TLS 1.2 as the minimum version and the
+// client certificate policy of opts.
+func (opts TLSOpts) TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	pool := opts.ClientCAs
+	if opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		if pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.ClientCAFile)
+		}
+	}
+	switch {
+	case opts.RequireClientCert && pool == nil:
+		return nil, errors.New("RequireClientCert needs ClientCAs or ClientCAFile")
+	case opts.RequireClientCert:
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	case pool != nil:
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	cfg.ClientCAs = pool
+	return cfg, nil
+}
+
+// ListenAndServeTLS listens on the configured address and serves requests
+// over TLS configured by opts. It returns http.ErrServerClosed after
+// Shutdown.
+func (s *Server) ListenAndServeTLS(opts TLSOpts) error {
+	addr := s.httpServer.Addr
+	if addr == "" {
+		addr = ":https"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeTLS(l, opts)
+}
+
+// ServeTLS serves requests over TLS configured by opts on connections
+// accepted on l.
+func (s *Server) ServeTLS(l net.Listener, opts TLSOpts) error {
+	cfg, err := opts.TLSConfig()
+	if err != nil {
+		l.Close()
+		return err
+	}
+	s.httpServer.TLSConfig = cfg
+	if opts.CertPrincipal != nil {
+		s.httpServer.Handler = Authenticate(s.httpServer.Handler, AuthOpts{
+			Authenticators: []Authenticator{&ClientCertAuth{Principal: opts.CertPrincipal}},
+			Optional:       !opts.RequireClientCert,
+		})
+	}
+	return s.httpServer.ServeTLS(l, "", "")
+}
+
+// ClientCertAuth authenticates requests by their verified TLS client
+// certificate.
+type ClientCertAuth struct {
+	// Principal maps the leaf certificate to a Principal; nil uses the
+	// certificate's common name as the Principal ID.
+	Principal func(cert *x509.Certificate) (*Principal, error)
+}
+
+// Authenticate implements Authenticator.
+func (a *ClientCertAuth) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil, nil
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	if a.Principal == nil {
+		return &Principal{ID: cert.Subject.CommonName}, nil
+	}
+	return a.Principal(cert)
+}
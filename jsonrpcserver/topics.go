@@ -0,0 +1,177 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Names of the methods served by EnableTopics.
+const (
+	TopicSubscribeMethod   = "topics.subscribe"
+	TopicUnsubscribeMethod = "topics.unsubscribe"
+	// TopicEventMethod is the notification method of topic events.
+	TopicEventMethod = "topics.event"
+)
+
+// TopicEvent is the result of a topic subscription notification.
+type TopicEvent struct {
+	// Seq increases by one with every published event. Clients pass the last
+	// Seq they saw as resumeFrom when subscribing again.
+	Seq   uint64 `json:"seq"`
+	Topic string `json:"topic"`
+	Data  any    `json:"data"`
+}
+
+// TopicOpts configures topic pub/sub.
+type TopicOpts struct {
+	// Authorize is consulted before a client subscribes to pattern; a non-nil
+	// error rejects the subscription and is returned to the client.
+	Authorize func(ctx context.Context, pattern string) error
+	// ReplayWindow is the number of recent events kept for clients resuming
+	// a subscription; zero disables replay.
+	ReplayWindow int
+}
+
+// EnableTopics serves topics.subscribe and topics.unsubscribe on m. Clients
+// subscribe to dot-separated topic patterns where "*" matches one segment
+// and a trailing "#" any number of segments, e.g. "orders.*.created" or
+// "orders.#". Events published with Hub.Publish are pushed as
+// topics.event notifications to every matching subscription.
+//
+// With a ReplayWindow, a client reconnecting after a brief disconnect passes
+// the Seq of the last event it saw as resumeFrom and first receives the
+// buffered matching events after it. Events older than the window are lost,
+// which the client sees as a gap in Seq. Replayed events may arrive before
+// the response carrying the subscription ID.
+func (m *Mux) EnableTopics(opts TopicOpts) {
+	m.hub.setReplayWindow(opts.ReplayWindow)
+	Register(m, TopicSubscribeMethod, func(ctx context.Context, args struct {
+		Pattern    string  `json:"pattern" validate:"required"`
+		ResumeFrom *uint64 `json:"resumeFrom"`
+	}) (string, error) {
+		if !validPattern(args.Pattern) {
+			return "", ErrInvalidParams("invalid topic pattern " + args.Pattern)
+		}
+		if opts.Authorize != nil {
+			if err := opts.Authorize(ctx, args.Pattern); err != nil {
+				return "", err
+			}
+		}
+		s := SessionFromContext(ctx)
+		if s == nil {
+			return "", NewError(CodeInvalidRequest, "invalid request", "subscriptions require a websocket connection")
+		}
+		return m.hub.subscribeTopic(s, args.Pattern, args.ResumeFrom)
+	})
+	Register(m, TopicUnsubscribeMethod, func(ctx context.Context, args struct {
+		Subscription string `json:"subscription" validate:"required"`
+	}) (bool, error) {
+		s := SessionFromContext(ctx)
+		return s != nil && s.Unsubscribe(args.Subscription), nil
+	})
+}
+
+// Publish pushes data to every topic subscription whose pattern matches
+// topic and returns how many WebSocket sessions queued it. Long-poll
+// subscriptions buffer it until their next poll.
+func (h *Hub) Publish(topic string, data any) (int, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	h.pubMu.Lock()
+	defer h.pubMu.Unlock()
+	h.seq++
+	event := TopicEvent{Seq: h.seq, Topic: topic, Data: json.RawMessage(raw)}
+	if h.replayWindow > 0 {
+		if len(h.replay) == h.replayWindow {
+			h.replay = h.replay[1:]
+		}
+		h.replay = append(h.replay, event)
+	}
+	if h.longPoll != nil {
+		h.longPoll.deliver(event)
+	}
+	sent := 0
+	for _, s := range h.Sessions() {
+		for _, sub := range s.Subscriptions() {
+			if sub.pattern == "" || !matchTopic(sub.pattern, topic) {
+				continue
+			}
+			ok, err := sub.push(event)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// setReplayWindow sizes the buffer of recent events.
+func (h *Hub) setReplayWindow(n int) {
+	h.pubMu.Lock()
+	defer h.pubMu.Unlock()
+	h.replayWindow = n
+}
+
+// subscribeTopic subscribes s to pattern and, when resumeFrom is set, queues
+// the buffered matching events after it. Publishing is held off meanwhile so
+// that no event is missed or repeated.
+func (h *Hub) subscribeTopic(s *Session, pattern string, resumeFrom *uint64) (string, error) {
+	h.pubMu.Lock()
+	defer h.pubMu.Unlock()
+	sub, err := s.subscribe(TopicEventMethod, pattern)
+	if err != nil {
+		return "", err
+	}
+	if resumeFrom == nil {
+		return sub.ID, nil
+	}
+	for _, event := range h.replay {
+		if event.Seq > *resumeFrom && matchTopic(pattern, event.Topic) {
+			if _, err := sub.push(event); err != nil {
+				return "", err
+			}
+		}
+	}
+	return sub.ID, nil
+}
+
+// push queues event as an update of the topic subscription sub.
+func (sub *Subscription) push(event TopicEvent) (bool, error) {
+	msg, err := json.Marshal(Notification{JSONRPC: "2.0", Method: sub.method, Params: SubscriptionUpdate{Subscription: sub.ID, Result: event}})
+	if err != nil {
+		return false, err
+	}
+	return sub.session.trySend(msg), nil
+}
+
+// validPattern reports whether pattern has no empty segments and "#" only
+// as its last segment.
+func validPattern(pattern string) bool {
+	segs := strings.Split(pattern, ".")
+	for i, seg := range segs {
+		if seg == "" || seg == "#" && i != len(segs)-1 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTopic reports whether topic matches pattern.
+func matchTopic(pattern, topic string) bool {
+	ps, ts := strings.Split(pattern, "."), strings.Split(topic, ".")
+	for i, p := range ps {
+		if p == "#" {
+			return true
+		}
+		if i >= len(ts) || p != "*" && p != ts[i] {
+			return false
+		}
+	}
+	return len(ps) == len(ts)
+}
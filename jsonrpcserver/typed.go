@@ -0,0 +1,54 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// Validator is implemented by params types that check themselves after
+// decoding. A failed validation is reported as invalid params.
+type Validator interface {
+	Validate() error
+}
+
+// Register registers fn for method on m. Params are decoded into Req,
+// checked against its `validate:"..."` tags as by BindParams and validated
+// when Req implements Validator, and the returned Resp is encoded as the
+// result, so fn only holds business logic.
+func Register[Req, Resp any](m *Mux, method string, fn func(ctx context.Context, req Req) (Resp, error)) {
+	kind := reflect.TypeFor[Req]().Kind()
+	unwrap := kind != reflect.Slice && kind != reflect.Array
+	serve := func(ctx context.Context, params json.RawMessage) (any, error) {
+		var req Req
+		if err := decodeServiceArgs(params, &req, unwrap); err != nil {
+			return nil, ErrInvalidParams(err.Error())
+		}
+		if errs := validateArgs(reflect.ValueOf(&req).Elem()); len(errs) > 0 {
+			return nil, ErrInvalidParams(errs)
+		}
+		if v, ok := any(&req).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, ErrInvalidParams(err.Error())
+			}
+		} else if v, ok := any(req).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, ErrInvalidParams(err.Error())
+			}
+		}
+		return fn(ctx, req)
+	}
+	m.Handle(method, typedHandler{HandlerFunc: serve, params: reflect.TypeFor[Req](), result: reflect.TypeFor[Resp]()})
+}
+
+// typedHandler is a handler registered through Register, which remembers its
+// types for rpc.discover.
+type typedHandler struct {
+	HandlerFunc
+	params, result reflect.Type
+}
+
+// describe documents the handler's params and result.
+func (h typedHandler) describe(name string) OpenRPCMethod {
+	return describeArgs(name, h.params, h.result)
+}
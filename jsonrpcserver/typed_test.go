@@ -0,0 +1,27 @@
+package jsonrpcserver
+
+import "testing"
+
+func TestRegisteredTopicArgsRequired(t *testing.T) {
+	m := NewMux()
+	m.EnableTopics(TopicOpts{})
+	for _, body := range []string{
+		`{"method":"` + TopicSubscribeMethod + `","params":{},"id":1}`,
+		`{"method":"` + TopicUnsubscribeMethod + `","params":{},"id":1}`,
+	} {
+		if got := callWith(t, m, nil, body); got != CodeInvalidParams {
+			t.Errorf("%s: code = %d, want %d", body, got, CodeInvalidParams)
+		}
+	}
+}
+
+func TestRegisteredPollArgsRequired(t *testing.T) {
+	m := NewMux()
+	m.EnableLongPoll(LongPollOpts{})
+	for _, method := range []string{PollSubscribeMethod, PollNextMethod, PollUnsubscribeMethod} {
+		body := `{"method":"` + method + `","params":{},"id":1}`
+		if got := callWith(t, m, nil, body); got != CodeInvalidParams {
+			t.Errorf("%s: code = %d, want %d", method, got, CodeInvalidParams)
+		}
+	}
+}
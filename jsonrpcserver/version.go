@@ -0,0 +1,219 @@
+package jsonrpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// VersionHeader selects the version of a versioned method for every request
+// of an HTTP exchange. A "version" member of named params takes precedence.
+const VersionHeader = "X-RPC-Version"
+
+// Deprecation describes a deprecated method or method version. Responses
+// to HTTP requests using it carry the Deprecation header, a Warning with
+// Message and, when set, a Sunset header.
+type Deprecation struct {
+	Message string
+	// Sunset is when the method will be removed.
+	Sunset time.Time
+}
+
+// versionedHandler serves the versions of one method.
+type versionedHandler struct {
+	mu         sync.RWMutex
+	method     string
+	versions   map[string]Handler
+	deprecated map[string]Deprecation
+	def        string
+	// scopes are those of the plain handler the versions replaced; they
+	// apply to versions that declare none.
+	scopes []string
+}
+
+// HandleVersion registers h as version of method. Requests pick a version
+// with a "version" member of their named params or the VersionHeader;
+// requests naming none get the default version, which is the first one
+// registered unless changed with SetDefaultVersion. Registering a version
+// replaces a plain handler of method; scopes it declared with
+// RequireScopes keep applying to versions that declare none.
+func (m *Mux) HandleVersion(method, version string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vh, ok := m.handlers[method].(*versionedHandler)
+	if !ok {
+		vh = &versionedHandler{method: method, versions: make(map[string]Handler), deprecated: make(map[string]Deprecation), def: version}
+		if old, ok := m.handlers[method]; ok {
+			vh.scopes = scopesOf(context.Background(), old, nil)
+		}
+		m.handlers[method] = vh
+	}
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+	vh.versions[version] = h
+}
+
+// SetDefaultVersion selects the version of method served to requests that
+// name none. It reports false when method has no such version.
+func (m *Mux) SetDefaultVersion(method, version string) bool {
+	vh := m.versioned(method)
+	if vh == nil {
+		return false
+	}
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+	if _, ok := vh.versions[version]; !ok {
+		return false
+	}
+	vh.def = version
+	return true
+}
+
+// DeprecateVersion marks version of method deprecated. It reports false
+// when method has no such version.
+func (m *Mux) DeprecateVersion(method, version string, d Deprecation) bool {
+	vh := m.versioned(method)
+	if vh == nil {
+		return false
+	}
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+	if _, ok := vh.versions[version]; !ok {
+		return false
+	}
+	vh.deprecated[version] = d
+	return true
+}
+
+// Deprecate marks method deprecated as a whole.
+func (m *Mux) Deprecate(method string, d Deprecation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deprecated[method] = d
+}
+
+// versioned returns the versioned handler of method, or nil.
+func (m *Mux) versioned(method string) *versionedHandler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	vh, _ := m.handlers[method].(*versionedHandler)
+	return vh
+}
+
+// selected returns the version handler the call with params selects.
+func (vh *versionedHandler) selected(ctx context.Context, params json.RawMessage) (Handler, bool) {
+	version := requestedVersion(ctx, params)
+	vh.mu.RLock()
+	defer vh.mu.RUnlock()
+	if version == "" {
+		version = vh.def
+	}
+	h, ok := vh.versions[version]
+	return h, ok
+}
+
+// ServeRPC dispatches to the requested version.
+func (vh *versionedHandler) ServeRPC(ctx context.Context, params json.RawMessage) (any, error) {
+	version := requestedVersion(ctx, params)
+	vh.mu.RLock()
+	if version == "" {
+		version = vh.def
+	}
+	h, ok := vh.versions[version]
+	d, deprecated := vh.deprecated[version]
+	vh.mu.RUnlock()
+	if !ok {
+		return nil, NewError(CodeMethodNotFound, "method not found", vh.method+" version "+version)
+	}
+	if deprecated {
+		noteDeprecation(ctx, vh.method, version, d)
+	}
+	return h.ServeRPC(ctx, params)
+}
+
+// describe documents the default version.
+func (vh *versionedHandler) describe(name string) OpenRPCMethod {
+	vh.mu.RLock()
+	h := vh.versions[vh.def]
+	vh.mu.RUnlock()
+	if d, ok := h.(describer); ok {
+		return d.describe(name)
+	}
+	return OpenRPCMethod{Name: name, Params: []OpenRPCContentDescriptor{}}
+}
+
+// requestedVersion returns the version named by the params or the HTTP
+// request, or "".
+func requestedVersion(ctx context.Context, params json.RawMessage) string {
+	if len(params) > 0 && params[0] == '{' {
+		var p struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(params, &p) == nil && p.Version != "" {
+			return p.Version
+		}
+	}
+	if r := HTTPRequestFromContext(ctx); r != nil {
+		return r.Header.Get(VersionHeader)
+	}
+	return ""
+}
+
+// deprecationNotes collects the deprecations used by one HTTP exchange.
+type deprecationNotes struct {
+	mu     sync.Mutex
+	notes  []string
+	sunset time.Time
+}
+
+// deprecationKey is the context key of the exchange's deprecationNotes.
+type deprecationKey struct{}
+
+// noteDeprecation records the use of a deprecated method for the response
+// headers and the metrics.
+func noteDeprecation(ctx context.Context, method, version string, d Deprecation) {
+	if mt, _ := ctx.Value(metricsKey{}).(*Metrics); mt != nil {
+		mt.observeDeprecated(method, version)
+	}
+	n, _ := ctx.Value(deprecationKey{}).(*deprecationNotes)
+	if n == nil {
+		return
+	}
+	name := method
+	if version != "" {
+		name += " " + version
+	}
+	msg := name + " is deprecated"
+	if d.Message != "" {
+		msg += ": " + d.Message
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notes = append(n.notes, msg)
+	if !d.Sunset.IsZero() && (n.sunset.IsZero() || d.Sunset.Before(n.sunset)) {
+		n.sunset = d.Sunset
+	}
+}
+
+// setDeprecationHeaders adds the headers announcing the deprecations noted
+// under ctx.
+func setDeprecationHeaders(ctx context.Context, h http.Header) {
+	n, _ := ctx.Value(deprecationKey{}).(*deprecationNotes)
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.notes) == 0 {
+		return
+	}
+	h.Set("Deprecation", "true")
+	for _, note := range n.notes {
+		h.Add("Warning", `299 - "`+note+`"`)
+	}
+	if !n.sunset.IsZero() {
+		h.Set("Sunset", n.sunset.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,227 @@
+package jsonrpcserver
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket opcodes.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// wsGUID is the key suffix of the opening handshake.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// defaultMaxMessageBytes bounds WebSocket messages when MaxRequestBytes is
+// not set.
+const defaultMaxMessageBytes = 32 << 20
+
+// errMessageTooLarge is returned by readMessage for oversized messages.
+var errMessageTooLarge = errors.New("websocket message too large")
+
+// isWebSocketUpgrade reports whether r asks to switch to WebSocket.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		headerHasToken(r.Header, "Connection", "upgrade") &&
+		headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+// headerHasToken reports whether the comma separated header contains token.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin allows requests without an Origin or from the endpoint's host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// wsConn is the server side of a WebSocket connection carrying text
+// messages.
+type wsConn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	maxBytes int64
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// upgrade performs the opening handshake and takes over the connection.
+func upgrade(w http.ResponseWriter, r *http.Request, maxBytes int64) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("unsupported websocket handshake")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("response writer does not support hijacking")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	sum := sha1.Sum([]byte(key + wsGUID))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " +
+		base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxMessageBytes
+	}
+	return &wsConn{conn: conn, br: brw.Reader, maxBytes: maxBytes}, nil
+}
+
+// readMessage returns the next data message, answering pings and close
+// frames on the way. It returns io.EOF once the peer closed the connection.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			c.writeFrame(wsClose, payload)
+			return nil, io.EOF
+		}
+		msg = append(msg, payload...)
+		if int64(len(msg)) > c.maxBytes {
+			c.closeWith(1009, "message too large")
+			return nil, errMessageTooLarge
+		}
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// readFrame reads one frame and unmasks its payload.
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin, op = head[0]&0x80 != 0, head[0]&0x0F
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if !masked {
+		c.closeWith(1002, "client frames must be masked")
+		return false, 0, nil, errors.New("unmasked client frame")
+	}
+	if n > uint64(c.maxBytes) {
+		c.closeWith(1009, "message too large")
+		return false, 0, nil, errMessageTooLarge
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeMessage sends data as a single text frame.
+func (c *wsConn) writeMessage(data []byte) error {
+	return c.writeFrame(wsText, data)
+}
+
+// writeFrame sends one unmasked, final frame.
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	head := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		head = append(head, byte(n))
+	case n <= 0xFFFF:
+		head = append(head, 126)
+		head = binary.BigEndian.AppendUint16(head, uint16(n))
+	default:
+		head = append(head, 127)
+		head = binary.BigEndian.AppendUint64(head, uint64(n))
+	}
+	if _, err := c.conn.Write(append(head, payload...)); err != nil {
+		return err
+	}
+	if op == wsClose {
+		c.closed = true
+	}
+	return nil
+}
+
+// closeWith sends a close frame with code and reason.
+func (c *wsConn) closeWith(code uint16, reason string) {
+	c.writeFrame(wsClose, append(binary.BigEndian.AppendUint16(nil, code), reason...))
+}
+
+// Close sends a going-away close frame and closes the connection.
+func (c *wsConn) Close() error {
+	c.closeWith(1001, "server shutting down")
+	return c.conn.Close()
+}